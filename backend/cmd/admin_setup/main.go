@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/serikkalibeknur/project-clothesstore/config"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -30,7 +31,7 @@ func main() {
 	defer client.Disconnect(ctx)
 
 	// Create admin user
-	userRepo := repository.NewUserRepository(client)
+	userRepo := repository.NewMongoUserRepository(client.Database(config.Load().DBName))
 
 	// Check if user already exists
 	existingUser, _ := userRepo.GetUserByEmail(ctx, *email)
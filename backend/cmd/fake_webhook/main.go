@@ -0,0 +1,64 @@
+// Command fake_webhook sends a payment event signed like the fake provider
+// would to a running server, for trying out the webhook endpoint locally.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+)
+
+func main() {
+	eventType := flag.String("type", models.PaymentEventCaptured, "Event type, e.g. payment.captured")
+	ref := flag.String("ref", "", "Provider reference of the payment")
+	amount := flag.Int64("amount", 0, "Total captured or refunded so far, in minor units")
+	reason := flag.String("reason", "", "Failure reason for payment.failed")
+	id := flag.String("id", "", "Event ID; generated when empty, set it to resend an event")
+	baseURL := flag.String("url", "http://localhost:8080", "Server URL")
+	flag.Parse()
+
+	if *ref == "" {
+		log.Fatal("-ref is required")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	cfg := config.Load()
+
+	provider := payments.NewFakeProvider(cfg.PaymentWebhookSecret)
+	payload, header, err := provider.SignEvent(models.PaymentEvent{
+		ID:     *id,
+		Type:   *eventType,
+		Ref:    *ref,
+		Amount: models.NewMoney(*amount),
+		Reason: *reason,
+	})
+	if err != nil {
+		log.Fatal("Failed to sign event:", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(*baseURL, "/")+"/api/webhooks/payments/"+provider.Name(), bytes.NewReader(payload))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header = header
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal("Failed to send event:", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("%s\n%s\n%s\n", payload, resp.Status, body)
+}
@@ -0,0 +1,154 @@
+// Command migrate_money converts the float prices and totals stored before the
+// Money type into {amount, currency} documents in minor units. Only numeric
+// fields are touched, so the command can be run again safely.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type migration struct {
+	collection string
+	filter     bson.M
+	update     mongo.Pipeline
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "Only count the documents that need converting")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	cfg := config.Load()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client, err := config.ConnectDB(ctx, cfg.MongoURI)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer client.Disconnect(context.Background())
+	db := client.Database(cfg.DBName)
+
+	for _, m := range migrations() {
+		collection := db.Collection(m.collection)
+		if *dryRun {
+			count, err := collection.CountDocuments(ctx, m.filter)
+			if err != nil {
+				log.Fatalf("Failed to count %s: %v", m.collection, err)
+			}
+			fmt.Printf("%s: %d documents to convert\n", m.collection, count)
+			continue
+		}
+
+		result, err := collection.UpdateMany(ctx, m.filter, m.update)
+		if err != nil {
+			log.Fatalf("Failed to convert %s: %v", m.collection, err)
+		}
+		fmt.Printf("%s: %d documents converted\n", m.collection, result.ModifiedCount)
+	}
+	if *dryRun {
+		return
+	}
+
+	// Indexes on the old float fields no longer match any query
+	dropIndex(ctx, db.Collection("products"), "category_1_price_1")
+	dropIndex(ctx, db.Collection("orders"), "totalPrice_1")
+	if err := repository.NewMongoRepositories(db).EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create indexes:", err)
+	}
+
+	fmt.Println("✓ Money migration complete")
+}
+
+func migrations() []migration {
+	isNumber := bson.M{"$type": "number"}
+
+	return []migration{
+		{
+			collection: "products",
+			filter:     bson.M{"$or": bson.A{bson.M{"price": isNumber}, bson.M{"salePrice": isNumber}, bson.M{"variants.price": isNumber}}},
+			update: mongo.Pipeline{{{Key: "$set", Value: bson.M{
+				"price":     moneyExpr("$price"),
+				"salePrice": moneyExpr("$salePrice"),
+				"variants":  mapArray("$variants", "v", bson.M{"price": moneyExpr("$$v.price")}),
+			}}}},
+		},
+		{
+			collection: "orders",
+			filter:     bson.M{"$or": bson.A{bson.M{"totalPrice": isNumber}, bson.M{"subtotal": isNumber}, bson.M{"products.price": isNumber}}},
+			update: mongo.Pipeline{{{Key: "$set", Value: bson.M{
+				"subtotal":          moneyExpr("$subtotal"),
+				"promotionDiscount": moneyExpr("$promotionDiscount"),
+				"discount":          moneyExpr("$discount"),
+				"totalPrice":        moneyExpr("$totalPrice"),
+				"products": mapArray("$products", "item", bson.M{
+					"price":    moneyExpr("$$item.price"),
+					"discount": moneyExpr("$$item.discount"),
+				}),
+			}}}},
+		},
+		{
+			// Coupons kept the percentage and the fixed amount in one value field
+			collection: "coupons",
+			filter:     bson.M{"value": bson.M{"$exists": true}},
+			update: mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{
+					"percent":       bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$type", models.CouponTypePercent}}, "$value", "$$REMOVE"}},
+					"amount":        bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$type", models.CouponTypeFixed}}, moneyExpr("$value"), "$$REMOVE"}},
+					"minOrderValue": moneyExpr("$minOrderValue"),
+				}}},
+				{{Key: "$unset", Value: "value"}},
+			},
+		},
+		{
+			collection: "promotions",
+			filter:     bson.M{"minSubtotal": isNumber},
+			update: mongo.Pipeline{{{Key: "$set", Value: bson.M{
+				"minSubtotal": moneyExpr("$minSubtotal"),
+			}}}},
+		},
+	}
+}
+
+// moneyExpr converts the number at path into a Money document. Anything else,
+// including a missing field or an already converted value, is left as is.
+func moneyExpr(path string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isNumber": path},
+		bson.M{
+			"amount":   bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{path, models.MinorUnitsPerMajor}}, 0}}},
+			"currency": models.DefaultCurrency,
+		},
+		path,
+	}}
+}
+
+// mapArray merges fields into every element of the array at path, referring to
+// the element as $$as.
+func mapArray(path, as string, fields bson.M) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isArray": path},
+		bson.M{"$map": bson.M{"input": path, "as": as, "in": bson.M{"$mergeObjects": bson.A{"$$" + as, fields}}}},
+		path,
+	}}
+}
+
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) {
+	if _, err := collection.Indexes().DropOne(ctx, name); err != nil {
+		log.Printf("Index %s on %s not dropped: %v", name, collection.Name(), err)
+	}
+}
@@ -0,0 +1,60 @@
+// Command replay_webhooks applies the stored payment webhook events that failed
+// to process, or were left unfinished, again, oldest first. Events that fail
+// once more stay failed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/controllers"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "Only list the events to replay")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	cfg := config.Load()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client, err := config.ConnectDB(ctx, cfg.MongoURI)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer client.Disconnect(context.Background())
+	repos := repository.NewMongoRepositories(client.Database(cfg.DBName))
+
+	providers := payments.NewRegistry(payments.NewFakeProvider(cfg.PaymentWebhookSecret))
+	app := controllers.NewApp(cfg, repos, log.Default(), utils.SystemClock{}, providers)
+
+	if *dryRun {
+		events, err := app.ReplayableWebhookEvents(ctx)
+		if err != nil {
+			log.Fatal("Failed to fetch webhook events:", err)
+		}
+		for _, e := range events {
+			fmt.Printf("%s %s %s %s %s (%d attempts): %s\n", e.Provider, e.Event.ID, e.Event.Type, e.Event.Ref, e.Status, e.Attempts, e.LastError)
+		}
+		fmt.Printf("%d events to replay\n", len(events))
+		return
+	}
+
+	replayed, err := app.ReplayFailedWebhookEvents(ctx)
+	if err != nil {
+		log.Fatal("Failed to replay webhook events:", err)
+	}
+	fmt.Printf("✓ %d webhook events replayed\n", replayed)
+}
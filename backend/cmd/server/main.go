@@ -4,13 +4,16 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/controllers"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
 	"github.com/serikkalibeknur/project-clothesstore/routes"
 )
 
@@ -20,39 +23,60 @@ func main() {
 		log.Println("No .env file found")
 	}
 
-	// Connect to MongoDB
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	cfg := config.Load()
+	logger := log.Default()
 
-	client, err := config.ConnectDB(ctx)
-	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
+	// Pick the storage backend; STORAGE=memory runs without a database
+	var repos *repository.Repositories
+	if cfg.Storage == "memory" {
+		logger.Println("Using in-memory storage, data will not persist")
+		repos = repository.NewMemoryRepositories()
+	} else {
+		// Connect to MongoDB
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client, err := config.ConnectDB(ctx, cfg.MongoURI)
+		if err != nil {
+			logger.Fatal("Failed to connect to MongoDB:", err)
+		}
+		defer client.Disconnect(context.Background())
+
+		repos = repository.NewMongoRepositories(client.Database(cfg.DBName))
+		if err := repos.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("Failed to create indexes:", err)
+		}
+	}
+
+	providers := payments.NewRegistry(payments.NewFakeProvider(cfg.PaymentWebhookSecret))
+	if _, ok := providers[cfg.PaymentProvider]; !ok {
+		logger.Fatalf("Unknown payment provider %q", cfg.PaymentProvider)
 	}
-	defer client.Disconnect(ctx)
+	if cfg.PaymentWebhookSecret == "" {
+		logger.Println("PAYMENT_WEBHOOK_SECRET is not set, payment webhooks will be rejected")
+	}
+
+	app := controllers.NewApp(cfg, repos, logger, utils.SystemClock{}, providers)
 
 	// Initialize router
 	router := mux.NewRouter()
 
 	// Setup routes
-	routes.SetupRoutes(router, client)
+	routes.SetupRoutes(router, app)
 
 	// CORS configuration
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "Idempotency-Key"},
+		ExposedHeaders: []string{"Idempotent-Replayed"},
 	})
 
 	handler := c.Handler(router)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Printf("Server starting on port %s", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
-		log.Fatal(err)
+	logger.Printf("Server starting on port %s", cfg.Port)
+	if err := http.ListenAndServe(":"+cfg.Port, handler); err != nil {
+		logger.Fatal(err)
 	}
 }
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Config holds the settings read from the environment at startup.
+type Config struct {
+	MongoURI  string
+	DBName    string
+	Port      string
+	JWTSecret string
+	Env       string
+	Storage   string // "mongo" or "memory"
+
+	PaymentProvider      string // name of the provider new payments go through
+	PaymentWebhookSecret string // signs and verifies payment webhooks
+
+	IdempotencyTTL time.Duration // how long responses to Idempotency-Key requests are kept
+}
+
+// Load reads the configuration from environment variables, applying the same
+// defaults the server has always used.
+func Load() *Config {
+	return &Config{
+		MongoURI:  getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+		DBName:    getEnv("DB_NAME", "clothes_store"),
+		Port:      getEnv("PORT", "8080"),
+		JWTSecret: os.Getenv("JWT_SECRET"),
+		Env:       getEnv("ENV", "development"),
+		Storage:   getEnv("STORAGE", "mongo"),
+
+		PaymentProvider:      getEnv("PAYMENT_PROVIDER", "fake"),
+		PaymentWebhookSecret: os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+
+		IdempotencyTTL: getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
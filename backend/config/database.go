@@ -3,7 +3,6 @@ package config
 import (
 	"context"
 	"log"
-	"os"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,12 +12,7 @@ import (
 var MongoClient *mongo.Client
 
 // ConnectDB connects to MongoDB
-func ConnectDB(ctx context.Context) (*mongo.Client, error) {
-	mongoURI := os.Getenv("MONGODB_URI")
-	if mongoURI == "" {
-		mongoURI = "mongodb://localhost:27017"
-	}
-
+func ConnectDB(ctx context.Context, mongoURI string) (*mongo.Client, error) {
 	clientOptions := options.Client().ApplyURI(mongoURI)
 
 	// Set connection timeout
@@ -49,21 +43,3 @@ func DisconnectDB(ctx context.Context) error {
 	}
 	return MongoClient.Disconnect(ctx)
 }
-
-// GetCollection returns a MongoDB collection
-func GetCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "clothes_store"
-	}
-	return client.Database(dbName).Collection(collectionName)
-}
-
-// GetDB returns a MongoDB database
-func GetDB(client *mongo.Client) *mongo.Database {
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "clothes_store"
-	}
-	return client.Database(dbName)
-}
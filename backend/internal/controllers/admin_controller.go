@@ -1,32 +1,173 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
-	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GetStatistics(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		orderRepo := repository.NewOrderRepository(client)
-		productRepo := repository.NewProductRepository(client)
-		userRepo := repository.NewUserRepository(client)
+const (
+	defaultAnalyticsRange = 30 * 24 * time.Hour
+	maxAnalyticsBuckets   = 400
+	defaultReportLimit    = 10
+)
+
+func (app *App) GetStatistics(w http.ResponseWriter, r *http.Request) {
+	// Get statistics
+	totalOrders, _ := app.Orders.GetTotalOrders(r.Context())
+	totalProducts, _ := app.Products.GetTotalProducts(r.Context())
+	totalUsers, _ := app.Users.GetTotalUsers(r.Context())
+	totalRevenue, _ := app.Orders.GetTotalRevenue(r.Context())
+
+	stats := map[string]interface{}{
+		"totalOrders":   totalOrders,
+		"totalProducts": totalProducts,
+		"totalUsers":    totalUsers,
+		"totalRevenue":  totalRevenue,
+	}
+
+	utils.SuccessResponse(w, "Statistics fetched successfully", stats)
+}
+
+// parseAnalyticsRange reads the from and to query parameters, defaulting to the
+// last 30 days.
+func (app *App) parseAnalyticsRange(values url.Values) (from, to time.Time, err error) {
+	fromParam, err := parseOptionalTime(values, "from", false)
+	if err != nil {
+		return from, to, err
+	}
+	toParam, err := parseOptionalTime(values, "to", true)
+	if err != nil {
+		return from, to, err
+	}
+
+	to = app.Clock.Now()
+	if toParam != nil {
+		to = *toParam
+	}
+	from = to.Add(-defaultAnalyticsRange)
+	if fromParam != nil {
+		from = *fromParam
+	}
 
-		// Get statistics
-		totalOrders, _ := orderRepo.GetTotalOrders(r.Context())
-		totalProducts, _ := productRepo.GetTotalProducts(r.Context())
-		totalUsers, _ := userRepo.GetTotalUsers(r.Context())
-		totalRevenue, _ := orderRepo.GetTotalRevenue(r.Context())
+	if !from.Before(to) {
+		return from, to, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
 
-		stats := map[string]interface{}{
-			"totalOrders":   totalOrders,
-			"totalProducts": totalProducts,
-			"totalUsers":    totalUsers,
-			"totalRevenue":  totalRevenue,
-		}
+// GetAnalytics reports revenue, order count and average order value per day,
+// week or month. The range defaults to the last 30 days and cancelled orders
+// are excluded.
+func (app *App) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query := models.SalesQuery{Interval: values.Get("interval")}
+	if query.Interval == "" {
+		query.Interval = models.IntervalDay
+	}
+	if !models.IsValidInterval(query.Interval) {
+		utils.ErrorResponse(w, "interval must be one of day, week, month", http.StatusBadRequest)
+		return
+	}
 
-		utils.SuccessResponse(w, "Statistics fetched successfully", stats)
+	var err error
+	if query.From, query.To, err = app.parseAnalyticsRange(values); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.BucketCount() > maxAnalyticsBuckets {
+		utils.ErrorResponse(w, fmt.Sprintf("Range is too long, at most %d %s buckets are allowed", maxAnalyticsBuckets, query.Interval), http.StatusBadRequest)
+		return
 	}
+
+	buckets, err := app.Orders.SalesTimeSeries(r.Context(), query)
+	if err != nil {
+		app.Logger.Printf("sales analytics: %v", err)
+		utils.ErrorResponse(w, "Failed to fetch analytics", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Analytics fetched successfully", models.NewSalesReport(query, buckets))
+}
+
+// GetReports returns the best sellers by units and by revenue, revenue per
+// category and per-product sell-through over a date range. The limit parameter
+// caps the ranked lists.
+func (app *App) GetReports(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	var query models.ReportQuery
+
+	var err error
+	if query.From, query.To, err = app.parseAnalyticsRange(values); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Limit, err = parseLimit(values, defaultReportLimit); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reports := models.SalesReports{From: query.From, To: query.To}
+	if reports.TopByUnits, err = app.Orders.TopProducts(r.Context(), query, models.RankByUnits); err != nil {
+		app.writeReportError(w, err)
+		return
+	}
+	if reports.TopByRevenue, err = app.Orders.TopProducts(r.Context(), query, models.RankByRevenue); err != nil {
+		app.writeReportError(w, err)
+		return
+	}
+	if reports.Categories, err = app.Orders.RevenueByCategory(r.Context(), query); err != nil {
+		app.writeReportError(w, err)
+		return
+	}
+	if reports.SellThrough, err = app.Orders.SellThrough(r.Context(), query); err != nil {
+		app.writeReportError(w, err)
+		return
+	}
+
+	utils.SuccessResponse(w, "Reports fetched successfully", reports)
+}
+
+// GetCustomerReport lists the best customers by lifetime spend, or by order
+// count with rank=orders, along with the repeat purchase rate and monthly
+// signup cohorts. Cancelled orders are not counted.
+func (app *App) GetCustomerReport(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	rankBy := values.Get("rank")
+	if rankBy == "" {
+		rankBy = models.RankBySpend
+	}
+	if rankBy != models.RankBySpend && rankBy != models.RankByOrders {
+		utils.ErrorResponse(w, "rank must be spend or orders", http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLimit(values, defaultPageLimit)
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := app.Orders.CustomerOrderStats(r.Context())
+	if err != nil {
+		app.writeReportError(w, err)
+		return
+	}
+	users, err := app.Users.GetAllUsers(r.Context())
+	if err != nil {
+		app.writeReportError(w, err)
+		return
+	}
+
+	utils.SuccessResponse(w, "Customer report fetched successfully", models.NewCustomerReport(users, stats, rankBy, limit))
+}
+
+func (app *App) writeReportError(w http.ResponseWriter, err error) {
+	app.Logger.Printf("reports: %v", err)
+	utils.ErrorResponse(w, "Failed to fetch reports", http.StatusInternalServerError)
 }
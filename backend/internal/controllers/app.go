@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"log"
+
+	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+)
+
+// App is built once at startup and carries every dependency the HTTP handlers
+// need. Handlers are methods on it, so wiring happens in a single place.
+type App struct {
+	*repository.Repositories
+	Config *config.Config
+	Logger *log.Logger
+	Clock  utils.Clock
+
+	PaymentProviders payments.Registry
+}
+
+func NewApp(cfg *config.Config, repos *repository.Repositories, logger *log.Logger, clock utils.Clock, providers payments.Registry) *App {
+	return &App{
+		Repositories:     repos,
+		Config:           cfg,
+		Logger:           logger,
+		Clock:            clock,
+		PaymentProviders: providers,
+	}
+}
@@ -3,97 +3,87 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
-	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
 	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func Register(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var input models.RegisterInput
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		if input.Email == "" || input.Password == "" || input.Name == "" {
-			utils.ErrorResponse(w, "Email, password, and name are required", http.StatusBadRequest)
-			return
-		}
-
-		userRepo := repository.NewUserRepository(client)
+func (app *App) Register(w http.ResponseWriter, r *http.Request) {
+	var input models.RegisterInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
 
-		// Check if user already exists
-		existingUser, _ := userRepo.GetUserByEmail(r.Context(), input.Email)
-		if existingUser != nil {
-			utils.ErrorResponse(w, "Email already registered", http.StatusConflict)
-			return
-		}
+	if input.Email == "" || input.Password == "" || input.Name == "" {
+		utils.ErrorResponse(w, "Email, password, and name are required", http.StatusBadRequest)
+		return
+	}
 
-		user := &models.User{
-			Name:      input.Name,
-			Email:     input.Email,
-			Password:  input.Password,
-			Role:      "user",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+	// Check if user already exists
+	existingUser, _ := app.Users.GetUserByEmail(r.Context(), input.Email)
+	if existingUser != nil {
+		utils.ErrorResponse(w, "Email already registered", http.StatusConflict)
+		return
+	}
 
-		if err := user.HashPassword(); err != nil {
-			utils.ErrorResponse(w, "Failed to process password", http.StatusInternalServerError)
-			return
-		}
+	user := &models.User{
+		Name:      input.Name,
+		Email:     input.Email,
+		Password:  input.Password,
+		Role:      "user",
+		CreatedAt: app.Clock.Now(),
+		UpdatedAt: app.Clock.Now(),
+	}
 
-		createdUser, err := userRepo.CreateUser(r.Context(), user)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to register user", http.StatusInternalServerError)
-			return
-		}
+	if err := user.HashPassword(); err != nil {
+		utils.ErrorResponse(w, "Failed to process password", http.StatusInternalServerError)
+		return
+	}
 
-		createdUser.Password = ""
-		utils.SuccessResponse(w, "User registered successfully", createdUser)
+	createdUser, err := app.Users.CreateUser(r.Context(), user)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to register user", http.StatusInternalServerError)
+		return
 	}
+
+	createdUser.Password = ""
+	utils.SuccessResponse(w, "User registered successfully", createdUser)
 }
 
-func Login(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var input models.LoginInput
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
+func (app *App) Login(w http.ResponseWriter, r *http.Request) {
+	var input models.LoginInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
 
-		if input.Email == "" || input.Password == "" {
-			utils.ErrorResponse(w, "Email and password are required", http.StatusBadRequest)
-			return
-		}
+	if input.Email == "" || input.Password == "" {
+		utils.ErrorResponse(w, "Email and password are required", http.StatusBadRequest)
+		return
+	}
 
-		userRepo := repository.NewUserRepository(client)
-		user, err := userRepo.GetUserByEmail(r.Context(), input.Email)
-		if err != nil || user == nil {
-			utils.ErrorResponse(w, "Invalid email or password", http.StatusUnauthorized)
-			return
-		}
+	user, err := app.Users.GetUserByEmail(r.Context(), input.Email)
+	if err != nil || user == nil {
+		utils.ErrorResponse(w, "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
 
-		if !user.ComparePassword(input.Password) {
-			utils.ErrorResponse(w, "Invalid email or password", http.StatusUnauthorized)
-			return
-		}
+	if !user.ComparePassword(input.Password) {
+		utils.ErrorResponse(w, "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
 
-		token, err := utils.GenerateJWT(user.ID.Hex(), user.Email, user.Role)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to generate token", http.StatusInternalServerError)
-			return
-		}
+	token, err := utils.GenerateJWT(app.Config.JWTSecret, user.ID.Hex(), user.Email, user.Role)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
 
-		user.Password = ""
-		response := map[string]interface{}{
-			"user":  user,
-			"token": token,
-		}
-		utils.SuccessResponse(w, "Login successful", response)
+	user.Password = ""
+	response := map[string]interface{}{
+		"user":  user,
+		"token": token,
 	}
+	utils.SuccessResponse(w, "Login successful", response)
 }
@@ -2,160 +2,346 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
 	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GetCart(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value("userID").(string)
-		userObjectID, err := primitive.ObjectIDFromHex(userID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
+var maxLineQuantityMessage = fmt.Sprintf("A cart can hold at most %d units of each item", models.MaxLineQuantity)
 
-		cartRepo := repository.NewCartRepository(client)
-		cart, err := cartRepo.GetCart(r.Context(), userObjectID)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to fetch cart", http.StatusInternalServerError)
-			return
+func (app *App) GetCart(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := app.Carts.GetCart(r.Context(), userObjectID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch cart", http.StatusInternalServerError)
+		return
+	}
+
+	if cart == nil {
+		cart = &models.Cart{
+			UserID:    userObjectID,
+			Products:  []models.CartItem{},
+			UpdatedAt: app.Clock.Now(),
 		}
+	}
 
-		if cart == nil {
-			cart = &models.Cart{
-				UserID:    userObjectID,
-				Products:  []models.CartItem{},
-				UpdatedAt: time.Now(),
-			}
+	// Tax and shipping are only previewed when the client says where the
+	// order will ship, using the same quote as checkout
+	query := r.URL.Query()
+	var destination *models.Address
+	if country := query.Get("country"); country != "" {
+		destination = &models.Address{
+			Country:    country,
+			Region:     query.Get("region"),
+			PostalCode: query.Get("postalCode"),
 		}
+	}
 
-		utils.SuccessResponse(w, "Cart fetched successfully", cart)
+	view, err := app.cartView(r.Context(), cart, destination, query.Get("shippingMethod"))
+	if errors.Is(err, models.ErrShippingMethodUnavailable) {
+		utils.ErrorResponse(w, "Shipping method is not available for this address", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to price cart", http.StatusInternalServerError)
+		return
 	}
+
+	utils.SuccessResponse(w, "Cart fetched successfully", view)
 }
 
-func AddToCart(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value("userID").(string)
-		userObjectID, err := primitive.ObjectIDFromHex(userID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
+func (app *App) AddToCart(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
 
-		var input models.AddToCartInput
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
+	var input models.AddToCartInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
 
-		if input.ProductID == "" || input.Quantity <= 0 {
-			utils.ErrorResponse(w, "Product ID and quantity are required", http.StatusBadRequest)
-			return
-		}
+	if input.ProductID == "" || input.Quantity <= 0 {
+		utils.ErrorResponse(w, "Product ID and quantity are required", http.StatusBadRequest)
+		return
+	}
+	if input.Quantity > models.MaxLineQuantity {
+		utils.ErrorResponse(w, maxLineQuantityMessage, http.StatusBadRequest)
+		return
+	}
 
-		productID, err := primitive.ObjectIDFromHex(input.ProductID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
-			return
-		}
+	productID, err := primitive.ObjectIDFromHex(input.ProductID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
 
-		// Verify product exists
-		productRepo := repository.NewProductRepository(client)
-		product, err := productRepo.GetProductByID(r.Context(), productID)
-		if err != nil || product == nil {
-			utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
-			return
-		}
+	// Verify product exists
+	product, err := app.Products.GetProductByID(r.Context(), productID)
+	if err != nil || product == nil {
+		utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
+		return
+	}
 
-		cartRepo := repository.NewCartRepository(client)
-		err = cartRepo.AddToCart(r.Context(), userObjectID, productID, input.Quantity, input.Size)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to add to cart", http.StatusInternalServerError)
-			return
-		}
+	item := models.CartItem{
+		ProductID: productID,
+		Quantity:  input.Quantity,
+		Size:      input.Size,
+		Color:     input.Color,
+		AddedAt:   app.Clock.Now(),
+	}
+
+	if err := selectVariant(product, &item, input.SKU); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cart, err := app.Carts.GetCart(r.Context(), userObjectID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch cart", http.StatusInternalServerError)
+		return
+	}
+	if cart != nil && item.Quantity+cart.VariantQuantity(item) > models.MaxLineQuantity {
+		utils.ErrorResponse(w, maxLineQuantityMessage, http.StatusBadRequest)
+		return
+	}
 
-		cart, _ := cartRepo.GetCart(r.Context(), userObjectID)
-		utils.SuccessResponse(w, "Added to cart successfully", cart)
+	err = app.Carts.AddToCart(r.Context(), userObjectID, item)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to add to cart", http.StatusInternalServerError)
+		return
 	}
+
+	cart, _ = app.Carts.GetCart(r.Context(), userObjectID)
+	utils.SuccessResponse(w, "Added to cart successfully", cart)
 }
 
-func UpdateCart(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value("userID").(string)
-		userObjectID, err := primitive.ObjectIDFromHex(userID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
+func (app *App) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
 
-		var input models.AddToCartInput
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-			return
+	lineID, err := primitive.ObjectIDFromHex(mux.Vars(r)["lineID"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid cart item ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.UpdateCartItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if input.Quantity < 0 {
+		utils.ErrorResponse(w, "Quantity cannot be negative", http.StatusBadRequest)
+		return
+	}
+	if input.Quantity > models.MaxLineQuantity {
+		utils.ErrorResponse(w, maxLineQuantityMessage, http.StatusBadRequest)
+		return
+	}
+
+	cart, err := app.Carts.GetCart(r.Context(), userObjectID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch cart", http.StatusInternalServerError)
+		return
+	}
+
+	existing := cart.FindLine(lineID)
+	if existing == nil {
+		utils.ErrorResponse(w, "Cart item not found", http.StatusNotFound)
+		return
+	}
+
+	if input.Quantity == 0 {
+		err = app.Carts.RemoveCartLine(r.Context(), userObjectID, lineID)
+	} else {
+		line := *existing
+		line.Quantity = input.Quantity
+
+		if (input.Size != "" && input.Size != line.Size) || (input.Color != "" && input.Color != line.Color) {
+			product, err := app.Products.GetProductByID(r.Context(), line.ProductID)
+			if err != nil || product == nil {
+				utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
+				return
+			}
+
+			if input.Size != "" {
+				line.Size = input.Size
+			}
+			if input.Color != "" {
+				line.Color = input.Color
+			}
+			if err := selectVariant(product, &line, ""); err != nil {
+				utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 		}
 
-		if input.ProductID == "" || input.Quantity < 0 {
-			utils.ErrorResponse(w, "Product ID and quantity are required", http.StatusBadRequest)
+		// A changed size or color may fold the line into another one
+		if line.Quantity+cart.VariantQuantity(line) > models.MaxLineQuantity {
+			utils.ErrorResponse(w, maxLineQuantityMessage, http.StatusBadRequest)
 			return
 		}
+		err = app.Carts.UpdateCartLine(r.Context(), userObjectID, line)
+	}
 
-		productID, err := primitive.ObjectIDFromHex(input.ProductID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+	if err != nil {
+		if errors.Is(err, repository.ErrCartLineNotFound) {
+			utils.ErrorResponse(w, "Cart item not found", http.StatusNotFound)
 			return
 		}
+		utils.ErrorResponse(w, "Failed to update cart", http.StatusInternalServerError)
+		return
+	}
 
-		cartRepo := repository.NewCartRepository(client)
-		if input.Quantity == 0 {
-			err = cartRepo.RemoveFromCart(r.Context(), userObjectID, productID)
-		} else {
-			err = cartRepo.UpdateCartItemQuantity(r.Context(), userObjectID, productID, input.Quantity)
-		}
+	cart, _ = app.Carts.GetCart(r.Context(), userObjectID)
+	utils.SuccessResponse(w, "Cart updated successfully", cart)
+}
+
+func (app *App) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	lineID, err := primitive.ObjectIDFromHex(mux.Vars(r)["lineID"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid cart item ID", http.StatusBadRequest)
+		return
+	}
 
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to update cart", http.StatusInternalServerError)
+	err = app.Carts.RemoveCartLine(r.Context(), userObjectID, lineID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCartLineNotFound) {
+			utils.ErrorResponse(w, "Cart item not found", http.StatusNotFound)
 			return
 		}
+		utils.ErrorResponse(w, "Failed to remove from cart", http.StatusInternalServerError)
+		return
+	}
+
+	cart, _ := app.Carts.GetCart(r.Context(), userObjectID)
+	utils.SuccessResponse(w, "Removed from cart successfully", cart)
+}
+
+// RemoveFromCart answers the retired product-wide removal, which dropped every
+// variant of a product at once. Clients remove single lines instead.
+func (app *App) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
+	utils.ErrorResponse(w, "Removing by product is no longer supported, use DELETE /api/cart/items/{lineID}", http.StatusGone)
+}
+
+// ApplyCoupon checks a coupon code against the current cart and, when it
+// applies, stores it on the cart so checkout uses it.
+func (app *App) ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.ApplyCouponInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if models.NormalizeCouponCode(input.Code) == "" {
+		utils.ErrorResponse(w, "Coupon code is required", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := app.Carts.GetCart(r.Context(), userObjectID)
+	if err != nil || cart == nil || len(cart.Products) == 0 {
+		utils.ErrorResponse(w, "Cart is empty", http.StatusBadRequest)
+		return
+	}
+
+	now := app.Clock.Now()
+	lines, err := app.pricedCartLines(r.Context(), cart, now)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to price cart", http.StatusInternalServerError)
+		return
+	}
+
+	quote, err := app.quote(r.Context(), quoteRequest{UserID: userObjectID, Lines: lines, CouponCode: input.Code, Now: now})
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to price cart", http.StatusInternalServerError)
+		return
+	}
+	if errors.Is(quote.CouponError, models.ErrCouponNotFound) {
+		utils.ErrorResponse(w, "Coupon not found", http.StatusNotFound)
+		return
+	}
+	if quote.CouponError != nil {
+		utils.ErrorResponse(w, "Coupon cannot be applied: "+quote.CouponError.Error(), http.StatusBadRequest)
+		return
+	}
 
-		cart, _ := cartRepo.GetCart(r.Context(), userObjectID)
-		utils.SuccessResponse(w, "Cart updated successfully", cart)
+	if err := app.Carts.SetCartCoupon(r.Context(), userObjectID, quote.Coupon.Code); err != nil {
+		utils.ErrorResponse(w, "Failed to apply coupon", http.StatusInternalServerError)
+		return
 	}
+
+	applied := models.AppliedCoupon{Code: quote.Coupon.Code, Discount: quote.Totals.CouponDiscount, Totals: quote.Totals}
+	utils.SuccessResponse(w, "Coupon applied successfully", applied)
 }
 
-func RemoveFromCart(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value("userID").(string)
-		userObjectID, err := primitive.ObjectIDFromHex(userID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
+func (app *App) RemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
 
-		vars := mux.Vars(r)
-		productID := vars["productID"]
+	if err := app.Carts.SetCartCoupon(r.Context(), userObjectID, ""); err != nil {
+		utils.ErrorResponse(w, "Failed to remove coupon", http.StatusInternalServerError)
+		return
+	}
 
-		productObjectID, err := primitive.ObjectIDFromHex(productID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
-			return
-		}
+	utils.SuccessResponse(w, "Coupon removed successfully", nil)
+}
 
-		cartRepo := repository.NewCartRepository(client)
-		err = cartRepo.RemoveFromCart(r.Context(), userObjectID, productObjectID)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to remove from cart", http.StatusInternalServerError)
-			return
+// selectVariant checks that item's size and color are offered by product and,
+// for products with variants, pins item to the matching variant's SKU.
+func selectVariant(product *models.Product, item *models.CartItem, sku string) error {
+	if len(product.Variants) == 0 {
+		if !product.OffersSize(item.Size) {
+			return errors.New("Size " + item.Size + " is not offered for this product")
 		}
+		return nil
+	}
 
-		cart, _ := cartRepo.GetCart(r.Context(), userObjectID)
-		utils.SuccessResponse(w, "Removed from cart successfully", cart)
+	variant := product.ResolveVariant(sku, item.Size, item.Color)
+	if variant == nil {
+		return errors.New("Selected size or color is not offered for this product")
 	}
+
+	item.SKU = variant.SKU
+	item.Size = variant.Size
+	item.Color = variant.Color
+	return nil
 }
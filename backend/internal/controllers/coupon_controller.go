@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (app *App) GetAllCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := app.Coupons.GetAllCoupons(r.Context())
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch coupons", http.StatusInternalServerError)
+		return
+	}
+
+	if coupons == nil {
+		coupons = []models.Coupon{}
+	}
+
+	utils.SuccessResponse(w, "Coupons fetched successfully", coupons)
+}
+
+func (app *App) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	var input models.CouponInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	coupon := couponFromInput(input)
+	coupon.CreatedAt = app.Clock.Now()
+	coupon.UpdatedAt = coupon.CreatedAt
+
+	if err := coupon.Normalize(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdCoupon, err := app.Coupons.CreateCoupon(r.Context(), coupon)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateCouponCode) {
+			utils.ErrorResponse(w, "Coupon code already exists", http.StatusConflict)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to create coupon", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Coupon created successfully", createdCoupon)
+}
+
+func (app *App) UpdateCoupon(w http.ResponseWriter, r *http.Request) {
+	couponID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CouponInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	coupon := couponFromInput(input)
+	coupon.UpdatedAt = app.Clock.Now()
+
+	if err := coupon.Normalize(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedCoupon, err := app.Coupons.UpdateCoupon(r.Context(), couponID, coupon)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateCouponCode) {
+			utils.ErrorResponse(w, "Coupon code already exists", http.StatusConflict)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to update coupon", http.StatusInternalServerError)
+		return
+	}
+	if updatedCoupon == nil {
+		utils.ErrorResponse(w, "Coupon not found", http.StatusNotFound)
+		return
+	}
+
+	utils.SuccessResponse(w, "Coupon updated successfully", updatedCoupon)
+}
+
+func (app *App) DeleteCoupon(w http.ResponseWriter, r *http.Request) {
+	couponID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid coupon ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Coupons.DeleteCoupon(r.Context(), couponID); err != nil {
+		utils.ErrorResponse(w, "Failed to delete coupon", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Coupon deleted successfully", nil)
+}
+
+func couponFromInput(input models.CouponInput) *models.Coupon {
+	coupon := &models.Coupon{
+		Code:          input.Code,
+		Type:          input.Type,
+		Percent:       input.Percent,
+		Amount:        input.Amount,
+		MinOrderValue: input.MinOrderValue,
+		ExpiresAt:     input.ExpiresAt,
+		UsageLimit:    input.UsageLimit,
+		PerUserLimit:  input.PerUserLimit,
+		Categories:    input.Categories,
+		ProductIDs:    input.ProductIDs,
+		Active:        true,
+	}
+	if input.Active != nil {
+		coupon.Active = *input.Active
+	}
+	return coupon
+}
@@ -1,184 +1,557 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"time"
+	"net/url"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
 	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func CreateOrder(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value("userID").(string)
-		userObjectID, err := primitive.ObjectIDFromHex(userID)
+// checkoutError aborts the checkout transaction with a client-facing message.
+type checkoutError struct {
+	message string
+	status  int
+}
+
+func (e *checkoutError) Error() string {
+	return e.message
+}
+
+func (app *App) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.CreateOrderInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if input.ShippingAddress.Street == "" || input.ShippingAddress.City == "" {
+		utils.ErrorResponse(w, "Shipping address is required", http.StatusBadRequest)
+		return
+	}
+
+	// Addresses covered by a shipping zone must pick one of its methods
+	if input.ShippingMethod == "" {
+		zone, err := app.shippingZone(r.Context(), input.ShippingAddress)
 		if err != nil {
-			utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+			utils.ErrorResponse(w, "Failed to create order", http.StatusInternalServerError)
 			return
 		}
-
-		var input models.CreateOrderInput
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		if zone != nil {
+			utils.ErrorResponse(w, "Shipping method is required", http.StatusBadRequest)
 			return
 		}
+	}
 
-		if input.ShippingAddress.Street == "" || input.ShippingAddress.City == "" {
-			utils.ErrorResponse(w, "Shipping address is required", http.StatusBadRequest)
-			return
-		}
+	provider, err := app.paymentProvider(app.Config.PaymentProvider)
+	if err != nil {
+		app.Logger.Println("Failed to create order:", err)
+		utils.ErrorResponse(w, "Failed to create order", http.StatusInternalServerError)
+		return
+	}
 
-		// Get user's cart
-		cartRepo := repository.NewCartRepository(client)
-		cart, err := cartRepo.GetCart(r.Context(), userObjectID)
-		if err != nil || cart == nil || len(cart.Products) == 0 {
-			utils.ErrorResponse(w, "Cart is empty", http.StatusBadRequest)
-			return
+	// The cart is read, stock decremented, the order and payment created and
+	// the cart cleared together, so a line added meanwhile is not cleared
+	// unpaid; the provider is only contacted once they have committed
+	var order *models.Order
+	var payment *models.Payment
+	err = app.Tx.RunInTransaction(r.Context(), func(ctx context.Context) error {
+		cart, err := app.Carts.GetCart(ctx, userObjectID)
+		if err != nil {
+			return err
+		}
+		if cart == nil || len(cart.Products) == 0 {
+			return &checkoutError{"Cart is empty", http.StatusBadRequest}
 		}
 
-		// Convert cart items to order items and calculate total
-		productRepo := repository.NewProductRepository(client)
+		now := app.Clock.Now()
 		var orderItems []models.OrderItem
-		totalPrice := 0.0
+		var lines []models.PricedLine
 
 		for _, cartItem := range cart.Products {
-			product, err := productRepo.GetProductByID(r.Context(), cartItem.ProductID)
-			if err != nil || product == nil {
-				utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
-				return
+			product, err := app.Products.GetProductByID(ctx, cartItem.ProductID)
+			if err != nil {
+				return err
+			}
+			if product == nil {
+				return &checkoutError{"Product not found", http.StatusNotFound}
 			}
 
-			if product.Stock < cartItem.Quantity {
-				utils.ErrorResponse(w, "Insufficient stock for product: "+product.Name, http.StatusBadRequest)
-				return
+			// Lines added before the product gained variants carry no SKU and
+			// are matched by size and color instead
+			var variant *models.ProductVariant
+			sku := ""
+			if len(product.Variants) > 0 {
+				if variant = product.ResolveVariant(cartItem.SKU, cartItem.Size, cartItem.Color); variant == nil {
+					return &checkoutError{"Selected variant is no longer available: " + product.Name, http.StatusBadRequest}
+				}
+				sku = variant.SKU
 			}
 
-			orderItem := models.OrderItem{
+			if err := app.Products.DecrementStock(ctx, product.ID, sku, cartItem.Quantity); err != nil {
+				if errors.Is(err, repository.ErrInsufficientStock) {
+					return &checkoutError{"Insufficient stock for product: " + product.Name, http.StatusBadRequest}
+				}
+				return err
+			}
+
+			price := product.VariantPrice(variant, now)
+			orderItems = append(orderItems, models.OrderItem{
 				ProductID: cartItem.ProductID,
+				SKU:       sku,
 				Name:      product.Name,
-				Price:     product.Price,
+				Price:     price,
 				Quantity:  cartItem.Quantity,
 				Size:      cartItem.Size,
-			}
-			orderItems = append(orderItems, orderItem)
-			totalPrice += product.Price * float64(cartItem.Quantity)
+				Color:     cartItem.Color,
+			})
+			lines = append(lines, models.PricedLine{
+				LineID:    cartItem.LineID,
+				ProductID: product.ID,
+				SKU:       sku,
+				Name:      product.Name,
+				Category:  product.Category,
+				UnitPrice: price,
+				Quantity:  cartItem.Quantity,
+				Weight:    product.Weight,
+			})
+		}
 
-			// Decrease product stock
-			newStock := product.Stock - cartItem.Quantity
-			product.Stock = newStock
-			productRepo.UpdateProduct(r.Context(), product.ID, product)
+		quote, err := app.quote(ctx, quoteRequest{
+			UserID:         userObjectID,
+			Lines:          lines,
+			CouponCode:     cart.CouponCode,
+			Destination:    &input.ShippingAddress,
+			ShippingMethod: input.ShippingMethod,
+			Now:            now,
+		})
+		if errors.Is(err, models.ErrShippingMethodUnavailable) {
+			return &checkoutError{"Shipping method is not available for this address", http.StatusBadRequest}
+		}
+		if err != nil {
+			return err
+		}
+		for i, line := range quote.Lines {
+			orderItems[i].Discount = line.Discount
+			orderItems[i].Promotion = line.Promotion
+			orderItems[i].Tax = line.Tax
+			orderItems[i].TaxRate = line.TaxRate
+		}
+		if err := app.redeemCoupon(ctx, quote, userObjectID); err != nil {
+			return err
 		}
 
-		// Create order
-		order := &models.Order{
-			UserID:          userObjectID,
-			Products:        orderItems,
-			TotalPrice:      totalPrice,
-			Status:          "pending",
+		order = &models.Order{
+			UserID:     userObjectID,
+			Products:   orderItems,
+			CouponCode: cart.CouponCode,
+			Status:     models.OrderStatusPending,
+			StatusHistory: []models.StatusChange{
+				{Status: models.OrderStatusPending, At: now, Actor: userID},
+			},
 			ShippingAddress: input.ShippingAddress,
-			CreatedAt:       time.Now(),
-			UpdatedAt:       time.Now(),
+			ShippingMethod:  input.ShippingMethod,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		order.SetTotals(quote.Totals)
+		if quote.Coupon != nil {
+			order.CouponID = quote.Coupon.ID
 		}
 
-		orderRepo := repository.NewOrderRepository(client)
-		createdOrder, err := orderRepo.CreateOrder(r.Context(), order)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to create order", http.StatusInternalServerError)
+		if order, err = app.Orders.CreateOrder(ctx, order); err != nil {
+			return err
+		}
+		if payment, err = app.createPayment(ctx, order, provider); err != nil {
+			return err
+		}
+		return app.Carts.ClearCart(ctx, userObjectID)
+	})
+	if err == nil {
+		payment, err = app.authorizePayment(r.Context(), order, payment, provider)
+	}
+	if err != nil {
+		var coErr *checkoutError
+		if errors.As(err, &coErr) {
+			utils.ErrorResponse(w, coErr.message, coErr.status)
 			return
 		}
+		app.Logger.Println("Failed to create order:", err)
+		utils.ErrorResponse(w, "Failed to create order", http.StatusInternalServerError)
+		return
+	}
 
-		// Clear cart after successful order
-		cartRepo.ClearCart(r.Context(), userObjectID)
+	utils.SuccessResponse(w, "Order created successfully", &models.CheckoutResult{Order: order, Payment: payment.Intent()})
+}
 
-		utils.SuccessResponse(w, "Order created successfully", createdOrder)
+// redeemCoupon records the use of the coupon the quote applied. Checkout fails
+// rather than silently dropping a coupon the customer asked for.
+func (app *App) redeemCoupon(ctx context.Context, quote *models.Quote, userID primitive.ObjectID) error {
+	if quote.CouponError != nil {
+		return &checkoutError{"Coupon cannot be applied: " + quote.CouponError.Error(), http.StatusBadRequest}
+	}
+	if quote.Coupon == nil {
+		return nil
 	}
-}
 
-func GetUserOrders(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value("userID").(string)
-		userObjectID, err := primitive.ObjectIDFromHex(userID)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
-			return
+	if err := app.Coupons.RedeemCoupon(ctx, quote.Coupon, userID); err != nil {
+		if errors.Is(err, repository.ErrCouponUnavailable) {
+			return &checkoutError{"Coupon cannot be applied: " + models.ErrCouponUsedUp.Error(), http.StatusConflict}
 		}
+		return err
+	}
+	return nil
+}
+
+func (app *App) GetUserOrders(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	orders, err := app.Orders.GetUserOrders(r.Context(), userObjectID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch orders", http.StatusInternalServerError)
+		return
+	}
+
+	if orders == nil {
+		orders = []models.Order{}
+	}
+
+	utils.SuccessResponse(w, "Orders fetched successfully", orders)
+}
+
+// GetAllOrders lists orders for admins, filtered by status, customer, creation
+// date and total, one page at a time.
+func (app *App) GetAllOrders(w http.ResponseWriter, r *http.Request) {
+	query, err := parseOrderQuery(r.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		orderRepo := repository.NewOrderRepository(client)
-		orders, err := orderRepo.GetUserOrders(r.Context(), userObjectID)
+	page := models.OrderPage{Items: []models.Order{}, Page: query.Page, Limit: query.Limit}
+
+	// A customer email is resolved to a user ID; an unknown email, or one
+	// that contradicts the userID parameter, matches no orders.
+	if email := r.URL.Query().Get("email"); email != "" {
+		user, err := app.Users.GetUserByEmail(r.Context(), email)
 		if err != nil {
 			utils.ErrorResponse(w, "Failed to fetch orders", http.StatusInternalServerError)
 			return
 		}
-
-		if orders == nil {
-			orders = []models.Order{}
+		if user == nil || (query.UserID != nil && *query.UserID != user.ID) {
+			utils.SuccessResponse(w, "Orders fetched successfully", page)
+			return
 		}
+		query.UserID = &user.ID
+	}
 
-		utils.SuccessResponse(w, "Orders fetched successfully", orders)
+	orders, total, err := app.Orders.ListOrders(r.Context(), query)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch orders", http.StatusInternalServerError)
+		return
 	}
+
+	if orders != nil {
+		page.Items = orders
+	}
+	page.Total = total
+	page.TotalPages = totalPages(total, query.Limit)
+	page.Next, page.Prev = pageLinks(r, page.Page, page.TotalPages)
+
+	utils.SuccessResponse(w, "Orders fetched successfully", page)
 }
 
-func GetAllOrders(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		orderRepo := repository.NewOrderRepository(client)
-		orders, err := orderRepo.GetAllOrders(r.Context())
+var orderSortFields = map[string]bool{"createdAt": true, "updatedAt": true, "totalPrice": true}
+
+func parseOrderQuery(values url.Values) (models.OrderQuery, error) {
+	query := models.OrderQuery{
+		Status: values.Get("status"),
+		Sort:   values.Get("sort"),
+	}
+
+	var err error
+	if query.Page, query.Limit, err = parsePagination(values); err != nil {
+		return query, err
+	}
+
+	if query.Status != "" && !models.IsValidOrderStatus(query.Status) {
+		return query, errors.New("Invalid order status")
+	}
+
+	if v := values.Get("userID"); v != "" {
+		userID, err := primitive.ObjectIDFromHex(v)
 		if err != nil {
-			utils.ErrorResponse(w, "Failed to fetch orders", http.StatusInternalServerError)
-			return
+			return query, errors.New("Invalid user ID")
 		}
+		query.UserID = &userID
+	}
 
-		if orders == nil {
-			orders = []models.Order{}
-		}
+	if query.From, err = parseOptionalTime(values, "from", false); err != nil {
+		return query, err
+	}
+	if query.To, err = parseOptionalTime(values, "to", true); err != nil {
+		return query, err
+	}
+	if query.MinTotal, err = parseOptionalMoney(values, "minTotal"); err != nil {
+		return query, err
+	}
+	if query.MaxTotal, err = parseOptionalMoney(values, "maxTotal"); err != nil {
+		return query, err
+	}
 
-		utils.SuccessResponse(w, "Orders fetched successfully", orders)
+	if query.Sort == "" {
+		query.Sort = "-createdAt"
 	}
+	if !orderSortFields[strings.TrimPrefix(query.Sort, "-")] {
+		return query, errors.New("sort must be one of createdAt, updatedAt, totalPrice, optionally prefixed with -")
+	}
+
+	return query, nil
 }
 
-func UpdateOrderStatus(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		orderID := vars["id"]
+func (app *App) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderID := vars["id"]
+
+	objectID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.UpdateOrderStatusInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
 
-		objectID, err := primitive.ObjectIDFromHex(orderID)
+	if !models.IsValidOrderStatus(input.Status) {
+		utils.ErrorResponse(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	order, err := app.Orders.GetOrderByID(r.Context(), objectID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch order", http.StatusInternalServerError)
+		return
+	}
+	if order == nil {
+		utils.ErrorResponse(w, "Order not found", http.StatusNotFound)
+		return
+	}
+
+	// Paid orders move to processing when their payment is captured; orders
+	// placed before payments were recorded have none and are moved by hand
+	if input.Status == models.OrderStatusProcessing {
+		payment, err := app.Payments.GetPaymentByOrder(r.Context(), order.ID)
 		if err != nil {
-			utils.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+			utils.ErrorResponse(w, "Failed to fetch payment", http.StatusInternalServerError)
 			return
 		}
-
-		var input struct {
-			Status string `json:"status"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		if payment != nil {
+			utils.ErrorResponse(w, "Orders move to processing when their payment is captured", http.StatusConflict)
 			return
 		}
+	}
+
+	actor := r.Context().Value("userID").(string)
+
+	var updatedOrder *models.Order
+	if input.Status == models.OrderStatusCancelled {
+		updatedOrder, err = app.cancelOrder(r.Context(), order, actor, input.Note)
+	} else {
+		updatedOrder, err = app.transitionOrder(r.Context(), order, input.Status, actor, input.Note)
+	}
+	if err != nil {
+		app.writeTransitionError(w, err)
+		return
+	}
+
+	utils.SuccessResponse(w, "Order status updated successfully", updatedOrder)
+}
+
+// GetOrder returns a single order with its status history and line totals.
+// Only the owner and admins can see it.
+func (app *App) GetOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := app.findOrderForUser(w, r, true)
+	if !ok {
+		return
+	}
 
-		validStatuses := map[string]bool{
-			"pending":    true,
-			"processing": true,
-			"shipped":    true,
-			"delivered":  true,
-			"cancelled":  true,
+	utils.SuccessResponse(w, "Order fetched successfully", models.NewOrderDetail(order))
+}
+
+// CancelOrder lets the owner of an order cancel it while it is still pending or
+// processing.
+func (app *App) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	var input models.CancelOrderInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, ok := app.findOrderForUser(w, r, false)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(string)
+	cancelledOrder, err := app.cancelOrder(r.Context(), order, userID, input.Reason)
+	if err != nil {
+		app.writeTransitionError(w, err)
+		return
+	}
+
+	utils.SuccessResponse(w, "Order cancelled successfully", cancelledOrder)
+}
+
+// findOrderForUser loads the order named in the URL and checks that the
+// requesting user owns it, or is an admin when allowAdmin is set. Orders the
+// user cannot see are reported as not found so their IDs are not leaked. It
+// writes the error response itself and reports whether the caller may go on.
+func (app *App) findOrderForUser(w http.ResponseWriter, r *http.Request, allowAdmin bool) (*models.Order, bool) {
+	userObjectID, err := primitive.ObjectIDFromHex(r.Context().Value("userID").(string))
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid order ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	order, err := app.Orders.GetOrderByID(r.Context(), orderID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch order", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	isAdmin := allowAdmin && r.Context().Value("role") == "admin"
+	if order == nil || (order.UserID != userObjectID && !isAdmin) {
+		utils.ErrorResponse(w, "Order not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return order, true
+}
+
+// cancelOrder cancels order and returns the stock of every line, and the use of
+// its coupon, in the same transaction. Because the status change is
+// conditional, stock is restored at most once even if two cancellations race.
+// A payment not captured yet is cancelled with the order; a captured one is
+// refunded afterwards.
+func (app *App) cancelOrder(ctx context.Context, order *models.Order, actor, reason string) (*models.Order, error) {
+	var cancelledOrder *models.Order
+	var payment *models.Payment
+	err := app.Tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		updated, err := app.transitionOrder(ctx, order, models.OrderStatusCancelled, actor, reason)
+		if err != nil {
+			return err
 		}
 
-		if !validStatuses[input.Status] {
-			utils.ErrorResponse(w, "Invalid status", http.StatusBadRequest)
-			return
+		for _, item := range order.Products {
+			if err := app.Products.IncrementStock(ctx, item.ProductID, item.SKU, item.Quantity); err != nil {
+				return err
+			}
 		}
 
-		orderRepo := repository.NewOrderRepository(client)
-		updatedOrder, err := orderRepo.UpdateOrderStatus(r.Context(), objectID, input.Status)
+		if !order.CouponID.IsZero() {
+			if err := app.Coupons.ReleaseCoupon(ctx, order.CouponID, order.UserID); err != nil {
+				return err
+			}
+		}
+
+		p, err := app.Payments.GetPaymentByOrder(ctx, order.ID)
 		if err != nil {
-			utils.ErrorResponse(w, "Failed to update order status", http.StatusInternalServerError)
-			return
+			return err
 		}
+		if p != nil && models.CanTransitionPayment(p.Status, models.PaymentCancelled) {
+			if p, err = app.transitionPayment(ctx, p, models.PaymentCancelled, nil); err != nil {
+				return err
+			}
+		}
+		payment = p
+
+		cancelledOrder = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The order stays cancelled if the refund or void fails; admins can retry
+	// a refund
+	switch {
+	case payment == nil:
+	case payment.Status == models.PaymentCaptured && payment.Refundable().IsPositive():
+		if _, err := app.refundPayment(ctx, payment, payment.Refundable()); err != nil {
+			app.Logger.Printf("Failed to refund payment %s of cancelled order %s: %v", payment.ID.Hex(), order.ID.Hex(), err)
+		}
+	case payment.Status == models.PaymentCancelled && payment.ProviderRef != "":
+		if err := app.voidPayment(ctx, payment); err != nil {
+			app.Logger.Printf("Failed to void payment %s of cancelled order %s: %v", payment.ID.Hex(), order.ID.Hex(), err)
+		}
+	}
+	return cancelledOrder, nil
+}
+
+// illegalTransitionError is returned by transitionOrder when the transition
+// table does not allow the requested change.
+type illegalTransitionError struct {
+	from, to string
+}
+
+func (e *illegalTransitionError) Error() string {
+	return "Cannot change order status from " + e.from + " to " + e.to
+}
+
+// transitionOrder moves order to status `to`, recording who made the change.
+func (app *App) transitionOrder(ctx context.Context, order *models.Order, to, actor, note string) (*models.Order, error) {
+	if !models.CanTransition(order.Status, to) {
+		return nil, &illegalTransitionError{from: order.Status, to: to}
+	}
+
+	return app.Orders.TransitionOrderStatus(ctx, order.ID, order.Status, models.StatusChange{
+		From:   order.Status,
+		Status: to,
+		At:     app.Clock.Now(),
+		Actor:  actor,
+		Note:   note,
+	})
+}
 
-		utils.SuccessResponse(w, "Order status updated successfully", updatedOrder)
+// writeTransitionError answers 409 for transitions the order's current status
+// does not allow, including ones lost to a concurrent update.
+func (app *App) writeTransitionError(w http.ResponseWriter, err error) {
+	var illegal *illegalTransitionError
+	switch {
+	case errors.As(err, &illegal):
+		utils.ErrorResponse(w, illegal.Error(), http.StatusConflict)
+	case errors.Is(err, repository.ErrOrderStatusChanged):
+		utils.ErrorResponse(w, "Order status was changed by another request, please retry", http.StatusConflict)
+	default:
+		app.Logger.Println("Failed to update order status:", err)
+		utils.ErrorResponse(w, "Failed to update order status", http.StatusInternalServerError)
 	}
 }
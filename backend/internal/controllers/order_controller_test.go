@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateOrderRollsBackOnInsufficientStock(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	app := NewApp(&config.Config{PaymentProvider: payments.FakeProviderName}, repos, log.New(io.Discard, "", 0), utils.SystemClock{}, payments.NewRegistry(payments.NewFakeProvider("")))
+
+	inStock := createProduct(t, repos, "Shirt", 5)
+	soldOut := createProduct(t, repos, "Jacket", 1)
+
+	userID := primitive.NewObjectID()
+	for _, item := range []models.CartItem{
+		{ProductID: inStock.ID, Quantity: 2, Size: "M"},
+		{ProductID: soldOut.ID, Quantity: 3, Size: "M"},
+	} {
+		if err := repos.Carts.AddToCart(ctx, userID, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	body := `{"shippingAddress":{"street":"1 Main St","city":"Springfield","country":"US"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), "userID", userID.Hex()))
+	w := httptest.NewRecorder()
+	app.CreateOrder(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+
+	// The first line's stock was decremented before the second failed
+	product, err := repos.Products.GetProductByID(ctx, inStock.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Stock != 5 {
+		t.Errorf("stock = %d after rollback, want 5", product.Stock)
+	}
+
+	cart, err := repos.Carts.GetCart(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cart.Products) != 2 {
+		t.Errorf("cart has %d lines after rollback, want 2", len(cart.Products))
+	}
+
+	orders, err := repos.Orders.GetUserOrders(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 0 {
+		t.Errorf("%d orders created, want none", len(orders))
+	}
+}
+
+func createProduct(t *testing.T, repos *repository.Repositories, name string, stock int) *models.Product {
+	t.Helper()
+	product, err := repos.Products.CreateProduct(context.Background(), &models.Product{
+		Name:      name,
+		Price:     models.NewMoney(2500),
+		Size:      []string{"M"},
+		Stock:     stock,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePagination reads the page and limit query parameters, applying defaults
+// and capping limit at maxPageLimit.
+func parsePagination(values url.Values) (page, limit int, err error) {
+	page = 1
+
+	if v := values.Get("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+	}
+
+	if limit, err = parseLimit(values, defaultPageLimit); err != nil {
+		return 0, 0, err
+	}
+
+	return page, limit, nil
+}
+
+// parseLimit reads the limit query parameter, capped at maxPageLimit.
+func parseLimit(values url.Values, fallback int) (int, error) {
+	v := values.Get("limit")
+	if v == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return min(limit, maxPageLimit), nil
+}
+
+func totalPages(total int64, limit int) int {
+	return int((total + int64(limit) - 1) / int64(limit))
+}
+
+// pageURL returns the request URL with its page parameter replaced, so next and
+// previous links keep every filter the client sent.
+func pageURL(r *http.Request, page int) string {
+	values := r.URL.Query()
+	values.Set("page", strconv.Itoa(page))
+	return r.URL.Path + "?" + values.Encode()
+}
+
+// pageLinks returns the next and previous page URLs, empty when there is none.
+func pageLinks(r *http.Request, page, pages int) (next, prev string) {
+	if page < pages {
+		next = pageURL(r, page+1)
+	}
+	if page > 1 {
+		prev = pageURL(r, page-1)
+	}
+	return next, prev
+}
+
+// parseOptionalMoney reads an amount given in major units, such as 19.99, as
+// query parameters are written by people rather than in minor units.
+func parseOptionalMoney(values url.Values, key string) (*models.Money, error) {
+	v := values.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, errors.New(key + " must be a number")
+	}
+	m := models.MoneyFromMajor(f)
+	return &m, nil
+}
+
+func parseOptionalBool(values url.Values, key string) (*bool, error) {
+	v := values.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, errors.New(key + " must be true or false")
+	}
+	return &b, nil
+}
+
+// parseOptionalTime accepts either an RFC 3339 timestamp or a YYYY-MM-DD date.
+// When endOfDay is set a bare date is moved to the start of the following day,
+// so it can be used as an exclusive upper bound that still covers the date.
+func parseOptionalTime(values url.Values, key string, endOfDay bool) (*time.Time, error) {
+	v := values.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, errors.New(key + " must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
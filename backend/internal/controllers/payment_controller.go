@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// paymentError is a payment failure with a client-facing message.
+type paymentError struct {
+	message string
+	status  int
+}
+
+func (e *paymentError) Error() string {
+	return e.message
+}
+
+// GetOrderPayment returns the payment of an order to its owner or an admin.
+func (app *App) GetOrderPayment(w http.ResponseWriter, r *http.Request) {
+	order, ok := app.findOrderForUser(w, r, true)
+	if !ok {
+		return
+	}
+
+	payment, err := app.Payments.GetPaymentByOrder(r.Context(), order.ID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch payment", http.StatusInternalServerError)
+		return
+	}
+	if payment == nil {
+		utils.ErrorResponse(w, "Payment not found", http.StatusNotFound)
+		return
+	}
+
+	utils.SuccessResponse(w, "Payment fetched successfully", payment)
+}
+
+// CapturePayment collects an authorized payment in full. The order moves to
+// processing once the provider confirms the capture.
+func (app *App) CapturePayment(w http.ResponseWriter, r *http.Request) {
+	payment, ok := app.findPayment(w, r)
+	if !ok {
+		return
+	}
+
+	if payment.Status != models.PaymentAuthorized {
+		utils.ErrorResponse(w, "Only authorized payments can be captured", http.StatusConflict)
+		return
+	}
+
+	provider, err := app.paymentProvider(payment.Provider)
+	if err != nil {
+		app.writePaymentError(w, err)
+		return
+	}
+
+	captured, err := provider.Capture(r.Context(), payment.ProviderRef, payment.Amount)
+	if err != nil {
+		if errors.Is(err, payments.ErrDeclined) {
+			if _, failErr := app.recordFailure(r.Context(), payment, err.Error()); failErr != nil {
+				app.Logger.Println("Failed to record declined capture:", failErr)
+			}
+			utils.ErrorResponse(w, "Payment capture was declined", http.StatusPaymentRequired)
+			return
+		}
+		app.Logger.Println("Failed to capture payment:", err)
+		utils.ErrorResponse(w, "Payment provider error", http.StatusBadGateway)
+		return
+	}
+
+	updated, err := app.recordCapture(r.Context(), payment, captured)
+	if err != nil {
+		app.writePaymentError(w, err)
+		return
+	}
+
+	utils.SuccessResponse(w, "Payment captured successfully", updated)
+}
+
+// RefundPayment returns part or all of a captured payment.
+func (app *App) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	var input models.RefundInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payment, ok := app.findPayment(w, r)
+	if !ok {
+		return
+	}
+
+	amount := payment.Refundable()
+	if input.Amount != nil {
+		if err := input.Amount.Validate(); err != nil {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		amount = *input.Amount
+	}
+
+	updated, err := app.refundPayment(r.Context(), payment, amount)
+	if err != nil {
+		app.writePaymentError(w, err)
+		return
+	}
+
+	utils.SuccessResponse(w, "Payment refunded successfully", updated)
+}
+
+// findPayment loads the payment named in the URL. It writes the error response
+// itself and reports whether the caller may go on.
+func (app *App) findPayment(w http.ResponseWriter, r *http.Request) (*models.Payment, bool) {
+	paymentID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid payment ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	payment, err := app.Payments.GetPaymentByID(r.Context(), paymentID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch payment", http.StatusInternalServerError)
+		return nil, false
+	}
+	if payment == nil {
+		utils.ErrorResponse(w, "Payment not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return payment, true
+}
+
+func (app *App) paymentProvider(name string) (payments.Provider, error) {
+	provider, ok := app.PaymentProviders[name]
+	if !ok {
+		return nil, errors.New("payment provider not configured: " + name)
+	}
+	return provider, nil
+}
+
+// createPayment records a pending payment for the order's total with the
+// configured provider. It runs inside the checkout transaction and does not
+// contact the provider, so a retried transaction has no side effects outside
+// the database.
+func (app *App) createPayment(ctx context.Context, order *models.Order, provider payments.Provider) (*models.Payment, error) {
+	now := app.Clock.Now()
+	return app.Payments.CreatePayment(ctx, &models.Payment{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Provider:  provider.Name(),
+		Amount:    order.TotalPrice,
+		Status:    models.PaymentPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+// authorizePayment asks the provider to authorize a payment created at
+// checkout, once the order is committed. If the provider refuses or fails,
+// the payment is marked failed and the order cancelled, which returns its
+// stock and coupon. An authorization that cannot be recorded, because the
+// order was cancelled in the meantime, is voided.
+func (app *App) authorizePayment(ctx context.Context, order *models.Order, payment *models.Payment, provider payments.Provider) (*models.Payment, error) {
+	auth, err := provider.Authorize(ctx, payments.AuthorizeRequest{OrderID: order.ID, Amount: payment.Amount})
+	if err != nil {
+		app.abandonCheckout(ctx, order, payment, err.Error())
+		if errors.Is(err, payments.ErrDeclined) {
+			return nil, &checkoutError{"Payment was declined", http.StatusPaymentRequired}
+		}
+		app.Logger.Println("Failed to authorize payment:", err)
+		return nil, &checkoutError{"Payment provider error", http.StatusBadGateway}
+	}
+
+	updated, err := app.transitionPayment(ctx, payment, auth.Status, func(p *models.Payment) {
+		p.ProviderRef = auth.Ref
+		p.ClientSecret = auth.ClientSecret
+	})
+	if err != nil {
+		if voidErr := provider.Void(ctx, auth.Ref); voidErr != nil {
+			app.Logger.Printf("Failed to void authorization %s of order %s: %v", auth.Ref, order.ID.Hex(), voidErr)
+		}
+		return nil, err
+	}
+	return updated, nil
+}
+
+// abandonCheckout marks a payment the provider did not authorize as failed
+// and cancels its order. Failures are logged; the order is already committed
+// and admins can cancel it by hand.
+func (app *App) abandonCheckout(ctx context.Context, order *models.Order, payment *models.Payment, reason string) {
+	if _, err := app.recordFailure(ctx, payment, reason); err != nil {
+		app.Logger.Printf("Failed to record failed payment %s: %v", payment.ID.Hex(), err)
+	}
+	if _, err := app.cancelOrder(ctx, order, "system", "Payment authorization failed"); err != nil {
+		app.Logger.Printf("Failed to cancel order %s after a failed payment: %v", order.ID.Hex(), err)
+	}
+}
+
+// transitionPayment moves payment to status `to` with the amounts set by
+// update, failing if the payment changed status in the meantime.
+func (app *App) transitionPayment(ctx context.Context, payment *models.Payment, to string, update func(*models.Payment)) (*models.Payment, error) {
+	if !models.CanTransitionPayment(payment.Status, to) {
+		return nil, &paymentError{"Cannot change payment status from " + payment.Status + " to " + to, http.StatusConflict}
+	}
+
+	change := *payment
+	change.Status = to
+	change.UpdatedAt = app.Clock.Now()
+	if update != nil {
+		update(&change)
+	}
+	return app.Payments.TransitionPayment(ctx, payment.Status, &change)
+}
+
+// recordCapture stores a capture the provider confirmed. Only a capture of the
+// full amount moves the order from pending to processing, in the same
+// transaction as the payment update.
+func (app *App) recordCapture(ctx context.Context, payment *models.Payment, captured models.Money) (*models.Payment, error) {
+	var updated *models.Payment
+	err := app.Tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		p, err := app.transitionPayment(ctx, payment, models.PaymentCaptured, func(p *models.Payment) {
+			p.Captured = captured
+		})
+		if err != nil {
+			return err
+		}
+		updated = p
+
+		if captured.Amount != payment.Amount.Amount {
+			app.Logger.Printf("Payment %s captured %s of %s, order left pending", payment.ID.Hex(), captured, payment.Amount)
+			return nil
+		}
+
+		order, err := app.Orders.GetOrderByID(ctx, payment.OrderID)
+		if err != nil {
+			return err
+		}
+		if order == nil || order.Status != models.OrderStatusPending {
+			return nil
+		}
+		_, err = app.transitionOrder(ctx, order, models.OrderStatusProcessing, "system", "Payment captured")
+		return err
+	})
+	return updated, err
+}
+
+// recordFailure marks a payment that has not been captured as failed.
+func (app *App) recordFailure(ctx context.Context, payment *models.Payment, reason string) (*models.Payment, error) {
+	return app.transitionPayment(ctx, payment, models.PaymentFailed, func(p *models.Payment) {
+		p.FailureReason = reason
+	})
+}
+
+// refundPayment returns amount of a captured payment through its provider and
+// records it. The payment becomes refunded once nothing is left to refund.
+func (app *App) refundPayment(ctx context.Context, payment *models.Payment, amount models.Money) (*models.Payment, error) {
+	if payment.Status != models.PaymentCaptured {
+		return nil, &paymentError{"Only captured payments can be refunded", http.StatusConflict}
+	}
+	if !amount.IsPositive() || payment.Refundable().Less(amount) {
+		return nil, &paymentError{"Refund must be positive and at most " + payment.Refundable().String(), http.StatusBadRequest}
+	}
+
+	provider, err := app.paymentProvider(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	refunded, err := provider.Refund(ctx, payment.ProviderRef, amount)
+	if err != nil {
+		if errors.Is(err, payments.ErrDeclined) {
+			return nil, &paymentError{"Refund was declined", http.StatusPaymentRequired}
+		}
+		return nil, err
+	}
+
+	return app.recordRefund(ctx, payment, refunded)
+}
+
+// recordRefund stores a refund the provider confirmed.
+func (app *App) recordRefund(ctx context.Context, payment *models.Payment, refunded models.Money) (*models.Payment, error) {
+	status := models.PaymentCaptured
+	if !payment.Refundable().Sub(refunded).IsPositive() {
+		status = models.PaymentRefunded
+	}
+	return app.transitionPayment(ctx, payment, status, func(p *models.Payment) {
+		p.Refunded = p.Refunded.Add(refunded)
+	})
+}
+
+// voidPayment releases the provider's hold on a payment that will not be
+// captured.
+func (app *App) voidPayment(ctx context.Context, payment *models.Payment) error {
+	provider, err := app.paymentProvider(payment.Provider)
+	if err != nil {
+		return err
+	}
+	return provider.Void(ctx, payment.ProviderRef)
+}
+
+// writePaymentError answers with the status carried by a paymentError, 409 for
+// payments or orders changed by a concurrent request and 500 otherwise.
+func (app *App) writePaymentError(w http.ResponseWriter, err error) {
+	var payErr *paymentError
+	switch {
+	case errors.As(err, &payErr):
+		utils.ErrorResponse(w, payErr.message, payErr.status)
+	case errors.Is(err, repository.ErrPaymentStatusChanged), errors.Is(err, repository.ErrOrderStatusChanged):
+		utils.ErrorResponse(w, "Payment was changed by another request, please retry", http.StatusConflict)
+	default:
+		app.Logger.Println("Failed to update payment:", err)
+		utils.ErrorResponse(w, "Failed to update payment", http.StatusInternalServerError)
+	}
+}
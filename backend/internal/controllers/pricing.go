@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pricedCartLines prices the cart lines at current product prices. Lines whose
+// product no longer exists are skipped.
+func (app *App) pricedCartLines(ctx context.Context, cart *models.Cart, now time.Time) ([]models.PricedLine, error) {
+	lines := []models.PricedLine{}
+	for _, item := range cart.Products {
+		product, err := app.Products.GetProductByID(ctx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			continue
+		}
+
+		variant := product.ResolveVariant(item.SKU, item.Size, item.Color)
+		lines = append(lines, models.PricedLine{
+			LineID:    item.LineID,
+			ProductID: product.ID,
+			SKU:       item.SKU,
+			Name:      product.Name,
+			Category:  product.Category,
+			UnitPrice: product.VariantPrice(variant, now),
+			Quantity:  item.Quantity,
+			Weight:    product.Weight,
+		})
+	}
+
+	return lines, nil
+}
+
+// quoteRequest is what a quote is priced from. Destination is the shipping
+// address tax is charged for; without one the quote carries no tax. Shipping
+// is only charged when ShippingMethod is set too.
+type quoteRequest struct {
+	UserID         primitive.ObjectID
+	Lines          []models.PricedLine
+	CouponCode     string
+	Destination    *models.Address
+	ShippingMethod string
+	Now            time.Time
+}
+
+// quote prices the request's lines: live promotions first, then the coupon,
+// then shipping and tax for the destination, and adds up the totals. The cart
+// view, the coupon preview and checkout all go through it so customers are
+// charged what they were shown. A coupon that does not apply is left out of
+// the totals and explained in CouponError.
+func (app *App) quote(ctx context.Context, req quoteRequest) (*models.Quote, error) {
+	promotions, err := app.Promotions.GetActivePromotions(ctx, req.Now)
+	if err != nil {
+		return nil, err
+	}
+	lines := req.Lines
+	models.ApplyPromotions(promotions, lines, req.Now)
+
+	quote := &models.Quote{Lines: lines, Totals: models.NewTotals(lines)}
+
+	if req.CouponCode != "" {
+		coupon, err := app.Coupons.GetCouponByCode(ctx, req.CouponCode)
+		if err != nil {
+			return nil, err
+		}
+		if coupon == nil {
+			quote.CouponError = models.ErrCouponNotFound
+		} else {
+			uses, err := app.Coupons.GetUserRedemptions(ctx, coupon.ID, req.UserID)
+			if err != nil {
+				return nil, err
+			}
+			if discount, err := coupon.Apply(uses, req.Now, lines); err != nil {
+				quote.CouponError = err
+			} else {
+				quote.Coupon = coupon
+				quote.Totals.CouponDiscount = discount
+			}
+		}
+	}
+
+	if req.Destination != nil && req.ShippingMethod != "" {
+		if err := app.applyShipping(ctx, quote, *req.Destination, req.ShippingMethod); err != nil {
+			return nil, err
+		}
+	}
+	if req.Destination != nil {
+		if err := app.applyTax(ctx, quote, *req.Destination); err != nil {
+			return nil, err
+		}
+	}
+
+	quote.Totals.Sum()
+	return quote, nil
+}
+
+// shippingZone returns the zone covering destination, or nil when the store
+// does not ship there.
+func (app *App) shippingZone(ctx context.Context, destination models.Address) (*models.ShippingZone, error) {
+	zones, err := app.ShippingZones.GetShippingZonesForCountry(ctx, models.AddressCode(destination.Country))
+	if err != nil {
+		return nil, err
+	}
+	return models.MatchShippingZone(zones, destination), nil
+}
+
+// applyShipping charges the method with code for destination, priced on the
+// goods after discounts. It returns models.ErrShippingMethodUnavailable when
+// no zone offers that method for the address.
+func (app *App) applyShipping(ctx context.Context, quote *models.Quote, destination models.Address, code string) error {
+	zone, err := app.shippingZone(ctx, destination)
+	if err != nil {
+		return err
+	}
+	var method *models.ShippingMethod
+	if zone != nil {
+		method = zone.Method(code)
+	}
+	if method == nil {
+		return models.ErrShippingMethodUnavailable
+	}
+
+	shipping := method.Quote(quote.Lines, quote.Totals.DiscountedSubtotal())
+	quote.Shipping = &shipping
+	quote.Totals.Shipping = shipping.Cost
+	return nil
+}
+
+// applyTax charges the tax rule matching destination on every line of quote,
+// after promotions and the coupon. Destinations without a rule are not taxed.
+func (app *App) applyTax(ctx context.Context, quote *models.Quote, destination models.Address) error {
+	rules, err := app.TaxRules.GetTaxRulesForCountry(ctx, models.AddressCode(destination.Country))
+	if err != nil {
+		return err
+	}
+	rule := models.MatchTaxRule(rules, destination)
+
+	var couponShares []models.Money
+	if quote.Coupon != nil {
+		couponShares = quote.Coupon.Allocate(quote.Lines, quote.Totals.CouponDiscount)
+	}
+	quote.Totals.Tax = models.ApplyTax(rule, quote.Lines, couponShares)
+	quote.Totals.TaxInclusive = rule != nil && rule.Inclusive
+	return nil
+}
+
+// cartView prices the cart for display, with tax for destination when one is
+// given and shipping when shippingMethod is too. A coupon that no longer
+// applies is reported in CouponError rather than failing the request.
+func (app *App) cartView(ctx context.Context, cart *models.Cart, destination *models.Address, shippingMethod string) (*models.CartView, error) {
+	now := app.Clock.Now()
+	lines, err := app.pricedCartLines(ctx, cart, now)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := app.quote(ctx, quoteRequest{
+		UserID:         cart.UserID,
+		Lines:          lines,
+		CouponCode:     cart.CouponCode,
+		Destination:    destination,
+		ShippingMethod: shippingMethod,
+		Now:            now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.CartView{Cart: cart, Lines: quote.Lines, Totals: quote.Totals}
+	if quote.CouponError != nil {
+		view.CouponError = quote.CouponError.Error()
+	}
+	return view, nil
+}
@@ -2,157 +2,295 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
-	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
 	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GetAllProducts(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		productRepo := repository.NewProductRepository(client)
+var productSortFields = map[string]bool{
+	"price":     true,
+	"createdAt": true,
+	"name":      true,
+}
 
-		products, err := productRepo.GetAllProducts(r.Context())
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to fetch products", http.StatusInternalServerError)
-			return
-		}
+func parseProductQuery(values url.Values) (models.ProductQuery, error) {
+	query := models.ProductQuery{
+		Category: values.Get("category"),
+		Size:     values.Get("size"),
+		Sort:     values.Get("sort"),
+	}
 
-		if products == nil {
-			products = []models.Product{}
-		}
+	var err error
+	if query.Page, query.Limit, err = parsePagination(values); err != nil {
+		return query, err
+	}
+	if query.MinPrice, err = parseOptionalMoney(values, "minPrice"); err != nil {
+		return query, err
+	}
+	if query.MaxPrice, err = parseOptionalMoney(values, "maxPrice"); err != nil {
+		return query, err
+	}
+	if query.InStock, err = parseOptionalBool(values, "inStock"); err != nil {
+		return query, err
+	}
 
-		utils.SuccessResponse(w, "Products fetched successfully", products)
+	if query.Sort != "" && !productSortFields[strings.TrimPrefix(query.Sort, "-")] {
+		return query, errors.New("sort must be one of price, createdAt, name, optionally prefixed with -")
 	}
+
+	return query, nil
 }
 
-func GetProductByID(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		id := vars["id"]
+func (app *App) GetAllProducts(w http.ResponseWriter, r *http.Request) {
+	query, err := parseProductQuery(r.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query.Now = app.Clock.Now()
+
+	products, total, err := app.Products.ListProducts(r.Context(), query)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch products", http.StatusInternalServerError)
+		return
+	}
 
-		productID, err := primitive.ObjectIDFromHex(id)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
-			return
-		}
+	if products == nil {
+		products = []models.Product{}
+	}
+	setEffectivePrices(products, query.Now)
 
-		productRepo := repository.NewProductRepository(client)
-		product, err := productRepo.GetProductByID(r.Context(), productID)
-		if err != nil || product == nil {
-			utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
-			return
-		}
+	facets, err := app.Products.ProductFacets(r.Context(), query)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch product facets", http.StatusInternalServerError)
+		return
+	}
 
-		utils.SuccessResponse(w, "Product fetched successfully", product)
+	page := models.ProductPage{
+		Items:      products,
+		Total:      total,
+		Page:       query.Page,
+		Limit:      query.Limit,
+		TotalPages: totalPages(total, query.Limit),
+		Facets:     facets,
 	}
+	page.Next, page.Prev = pageLinks(r, page.Page, page.TotalPages)
+
+	utils.SuccessResponse(w, "Products fetched successfully", page)
 }
 
-func CreateProduct(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var input models.ProductInput
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		if input.Name == "" || input.Price <= 0 {
-			utils.ErrorResponse(w, "Product name and price are required and price must be positive", http.StatusBadRequest)
-			return
-		}
-
-		product := &models.Product{
-			Name:        input.Name,
-			Description: input.Description,
-			Price:       input.Price,
-			Size:        input.Size,
-			Category:    input.Category,
-			ImageURL:    input.ImageURL,
-			Stock:       input.Stock,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-
-		productRepo := repository.NewProductRepository(client)
-		createdProduct, err := productRepo.CreateProduct(r.Context(), product)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to create product", http.StatusInternalServerError)
-			return
-		}
-
-		utils.SuccessResponse(w, "Product created successfully", createdProduct)
+func (app *App) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	q := strings.TrimSpace(values.Get("q"))
+	if q == "" {
+		utils.ErrorResponse(w, "Search query is required", http.StatusBadRequest)
+		return
+	}
+
+	pageNum, limit, err := parsePagination(values)
+	if err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, total, err := app.Products.SearchProducts(r.Context(), q, pageNum, limit)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to search products", http.StatusInternalServerError)
+		return
 	}
+
+	if products == nil {
+		products = []models.Product{}
+	}
+	setEffectivePrices(products, app.Clock.Now())
+
+	page := models.ProductPage{
+		Items:      products,
+		Total:      total,
+		Page:       pageNum,
+		Limit:      limit,
+		TotalPages: totalPages(total, limit),
+	}
+	page.Next, page.Prev = pageLinks(r, page.Page, page.TotalPages)
+
+	utils.SuccessResponse(w, "Products fetched successfully", page)
 }
 
-func UpdateProduct(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		id := vars["id"]
-
-		productID, err := primitive.ObjectIDFromHex(id)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
-			return
-		}
-
-		var input models.ProductInput
-		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		productRepo := repository.NewProductRepository(client)
-
-		// Get existing product
-		existingProduct, err := productRepo.GetProductByID(r.Context(), productID)
-		if err != nil || existingProduct == nil {
-			utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
-			return
-		}
-
-		// Update fields
-		existingProduct.Name = input.Name
-		existingProduct.Description = input.Description
-		existingProduct.Price = input.Price
-		existingProduct.Size = input.Size
-		existingProduct.Category = input.Category
-		existingProduct.ImageURL = input.ImageURL
-		existingProduct.Stock = input.Stock
-		existingProduct.UpdatedAt = time.Now()
-
-		updatedProduct, err := productRepo.UpdateProduct(r.Context(), productID, existingProduct)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to update product", http.StatusInternalServerError)
-			return
-		}
-
-		utils.SuccessResponse(w, "Product updated successfully", updatedProduct)
+func (app *App) GetProductByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	productID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	product, err := app.Products.GetProductByID(r.Context(), productID)
+	if err != nil || product == nil {
+		utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
+		return
 	}
+	product.SetEffectivePrice(app.Clock.Now())
+
+	utils.SuccessResponse(w, "Product fetched successfully", product)
+}
+
+func (app *App) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var input models.ProductInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if input.Name == "" || !input.Price.IsPositive() {
+		utils.ErrorResponse(w, "Product name and price are required and price must be positive", http.StatusBadRequest)
+		return
+	}
+	if err := input.Price.Validate(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Weight < 0 {
+		utils.ErrorResponse(w, "Weight cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	product := &models.Product{
+		ID:          primitive.NewObjectID(),
+		Name:        input.Name,
+		Description: input.Description,
+		Price:       input.Price,
+		Size:        input.Size,
+		Category:    input.Category,
+		ImageURL:    input.ImageURL,
+		Stock:       input.Stock,
+		Weight:      input.Weight,
+		Variants:    input.Variants,
+
+		SalePrice:    input.SalePrice,
+		SaleStartsAt: input.SaleStartsAt,
+		SaleEndsAt:   input.SaleEndsAt,
+
+		CreatedAt: app.Clock.Now(),
+		UpdatedAt: app.Clock.Now(),
+	}
+
+	if err := product.NormalizeVariants(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := product.ValidateSale(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdProduct, err := app.Products.CreateProduct(r.Context(), product)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to create product", http.StatusInternalServerError)
+		return
+	}
+	createdProduct.SetEffectivePrice(app.Clock.Now())
+
+	utils.SuccessResponse(w, "Product created successfully", createdProduct)
+}
+
+func (app *App) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	productID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.ProductInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Get existing product
+	existingProduct, err := app.Products.GetProductByID(r.Context(), productID)
+	if err != nil || existingProduct == nil {
+		utils.ErrorResponse(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	if err := input.Price.Validate(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Weight < 0 {
+		utils.ErrorResponse(w, "Weight cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	// Update fields
+	existingProduct.Name = input.Name
+	existingProduct.Description = input.Description
+	existingProduct.Price = input.Price
+	existingProduct.Size = input.Size
+	existingProduct.Category = input.Category
+	existingProduct.ImageURL = input.ImageURL
+	existingProduct.Stock = input.Stock
+	existingProduct.Weight = input.Weight
+	existingProduct.Variants = input.Variants
+	existingProduct.SalePrice = input.SalePrice
+	existingProduct.SaleStartsAt = input.SaleStartsAt
+	existingProduct.SaleEndsAt = input.SaleEndsAt
+	existingProduct.UpdatedAt = app.Clock.Now()
+
+	if err := existingProduct.NormalizeVariants(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := existingProduct.ValidateSale(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedProduct, err := app.Products.UpdateProduct(r.Context(), productID, existingProduct)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to update product", http.StatusInternalServerError)
+		return
+	}
+	updatedProduct.SetEffectivePrice(app.Clock.Now())
+
+	utils.SuccessResponse(w, "Product updated successfully", updatedProduct)
+}
+
+func (app *App) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	productID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	err = app.Products.DeleteProduct(r.Context(), productID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to delete product", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Product deleted successfully", nil)
 }
 
-func DeleteProduct(client *mongo.Client) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		id := vars["id"]
-
-		productID, err := primitive.ObjectIDFromHex(id)
-		if err != nil {
-			utils.ErrorResponse(w, "Invalid product ID", http.StatusBadRequest)
-			return
-		}
-
-		productRepo := repository.NewProductRepository(client)
-		err = productRepo.DeleteProduct(r.Context(), productID)
-		if err != nil {
-			utils.ErrorResponse(w, "Failed to delete product", http.StatusInternalServerError)
-			return
-		}
-
-		utils.SuccessResponse(w, "Product deleted successfully", nil)
+// setEffectivePrices fills in the derived sale pricing of listed products.
+func setEffectivePrices(products []models.Product, now time.Time) {
+	for i := range products {
+		products[i].SetEffectivePrice(now)
 	}
 }
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (app *App) GetAllPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := app.Promotions.GetAllPromotions(r.Context())
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch promotions", http.StatusInternalServerError)
+		return
+	}
+
+	if promotions == nil {
+		promotions = []models.Promotion{}
+	}
+
+	utils.SuccessResponse(w, "Promotions fetched successfully", promotions)
+}
+
+func (app *App) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var input models.PromotionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promotion := promotionFromInput(input)
+	promotion.CreatedAt = app.Clock.Now()
+	promotion.UpdatedAt = promotion.CreatedAt
+
+	if err := promotion.Validate(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdPromotion, err := app.Promotions.CreatePromotion(r.Context(), promotion)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to create promotion", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Promotion created successfully", createdPromotion)
+}
+
+func (app *App) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	promotionID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.PromotionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	promotion := promotionFromInput(input)
+	promotion.UpdatedAt = app.Clock.Now()
+
+	if err := promotion.Validate(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedPromotion, err := app.Promotions.UpdatePromotion(r.Context(), promotionID, promotion)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to update promotion", http.StatusInternalServerError)
+		return
+	}
+	if updatedPromotion == nil {
+		utils.ErrorResponse(w, "Promotion not found", http.StatusNotFound)
+		return
+	}
+
+	utils.SuccessResponse(w, "Promotion updated successfully", updatedPromotion)
+}
+
+func (app *App) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	promotionID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Promotions.DeletePromotion(r.Context(), promotionID); err != nil {
+		utils.ErrorResponse(w, "Failed to delete promotion", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Promotion deleted successfully", nil)
+}
+
+func promotionFromInput(input models.PromotionInput) *models.Promotion {
+	promotion := &models.Promotion{
+		Name:          input.Name,
+		Type:          input.Type,
+		Priority:      input.Priority,
+		Active:        true,
+		StartsAt:      input.StartsAt,
+		EndsAt:        input.EndsAt,
+		MinSubtotal:   input.MinSubtotal,
+		Categories:    input.Categories,
+		ProductIDs:    input.ProductIDs,
+		Percent:       input.Percent,
+		BuyQuantity:   input.BuyQuantity,
+		FreeQuantity:  input.FreeQuantity,
+		FreeProductID: input.FreeProductID,
+	}
+	if input.Active != nil {
+		promotion.Active = *input.Active
+	}
+	return promotion
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetShippingQuotes prices every shipping method available for the current
+// cart at the address given by the country, region and postalCode query
+// parameters. Addresses outside every zone get no quotes.
+func (app *App) GetShippingQuotes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(string)
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	destination := models.Address{
+		Country:    query.Get("country"),
+		Region:     query.Get("region"),
+		PostalCode: query.Get("postalCode"),
+	}
+	if destination.Country == "" {
+		utils.ErrorResponse(w, "Country is required", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := app.Carts.GetCart(r.Context(), userObjectID)
+	if err != nil || cart == nil || len(cart.Products) == 0 {
+		utils.ErrorResponse(w, "Cart is empty", http.StatusBadRequest)
+		return
+	}
+
+	now := app.Clock.Now()
+	lines, err := app.pricedCartLines(r.Context(), cart, now)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to price cart", http.StatusInternalServerError)
+		return
+	}
+	quote, err := app.quote(r.Context(), quoteRequest{UserID: userObjectID, Lines: lines, CouponCode: cart.CouponCode, Now: now})
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to price cart", http.StatusInternalServerError)
+		return
+	}
+
+	zone, err := app.shippingZone(r.Context(), destination)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch shipping quotes", http.StatusInternalServerError)
+		return
+	}
+
+	quotes := []models.ShippingQuote{}
+	if zone != nil {
+		quotes = zone.Quotes(quote.Lines, quote.Totals.DiscountedSubtotal())
+	}
+
+	utils.SuccessResponse(w, "Shipping quotes fetched successfully", quotes)
+}
+
+func (app *App) GetAllShippingZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := app.ShippingZones.GetAllShippingZones(r.Context())
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch shipping zones", http.StatusInternalServerError)
+		return
+	}
+
+	if zones == nil {
+		zones = []models.ShippingZone{}
+	}
+
+	utils.SuccessResponse(w, "Shipping zones fetched successfully", zones)
+}
+
+func (app *App) CreateShippingZone(w http.ResponseWriter, r *http.Request) {
+	var input models.ShippingZoneInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zone := shippingZoneFromInput(input)
+	zone.CreatedAt = app.Clock.Now()
+	zone.UpdatedAt = zone.CreatedAt
+
+	if err := zone.Normalize(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdZone, err := app.ShippingZones.CreateShippingZone(r.Context(), zone)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to create shipping zone", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Shipping zone created successfully", createdZone)
+}
+
+func (app *App) UpdateShippingZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid shipping zone ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.ShippingZoneInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zone := shippingZoneFromInput(input)
+	zone.UpdatedAt = app.Clock.Now()
+
+	if err := zone.Normalize(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedZone, err := app.ShippingZones.UpdateShippingZone(r.Context(), zoneID, zone)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to update shipping zone", http.StatusInternalServerError)
+		return
+	}
+	if updatedZone == nil {
+		utils.ErrorResponse(w, "Shipping zone not found", http.StatusNotFound)
+		return
+	}
+
+	utils.SuccessResponse(w, "Shipping zone updated successfully", updatedZone)
+}
+
+func (app *App) DeleteShippingZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid shipping zone ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.ShippingZones.DeleteShippingZone(r.Context(), zoneID); err != nil {
+		utils.ErrorResponse(w, "Failed to delete shipping zone", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Shipping zone deleted successfully", nil)
+}
+
+func shippingZoneFromInput(input models.ShippingZoneInput) *models.ShippingZone {
+	return &models.ShippingZone{
+		Name:           input.Name,
+		Countries:      input.Countries,
+		PostalPrefixes: input.PostalPrefixes,
+		Methods:        input.Methods,
+	}
+}
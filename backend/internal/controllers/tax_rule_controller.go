@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (app *App) GetAllTaxRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := app.TaxRules.GetAllTaxRules(r.Context())
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to fetch tax rules", http.StatusInternalServerError)
+		return
+	}
+
+	if rules == nil {
+		rules = []models.TaxRule{}
+	}
+
+	utils.SuccessResponse(w, "Tax rules fetched successfully", rules)
+}
+
+func (app *App) CreateTaxRule(w http.ResponseWriter, r *http.Request) {
+	var input models.TaxRuleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule := taxRuleFromInput(input)
+	rule.CreatedAt = app.Clock.Now()
+	rule.UpdatedAt = rule.CreatedAt
+
+	if err := rule.Normalize(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdRule, err := app.TaxRules.CreateTaxRule(r.Context(), rule)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateTaxRule) {
+			utils.ErrorResponse(w, "A tax rule for this country and region already exists", http.StatusConflict)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to create tax rule", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Tax rule created successfully", createdRule)
+}
+
+func (app *App) UpdateTaxRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid tax rule ID", http.StatusBadRequest)
+		return
+	}
+
+	var input models.TaxRuleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule := taxRuleFromInput(input)
+	rule.UpdatedAt = app.Clock.Now()
+
+	if err := rule.Normalize(); err != nil {
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedRule, err := app.TaxRules.UpdateTaxRule(r.Context(), ruleID, rule)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateTaxRule) {
+			utils.ErrorResponse(w, "A tax rule for this country and region already exists", http.StatusConflict)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to update tax rule", http.StatusInternalServerError)
+		return
+	}
+	if updatedRule == nil {
+		utils.ErrorResponse(w, "Tax rule not found", http.StatusNotFound)
+		return
+	}
+
+	utils.SuccessResponse(w, "Tax rule updated successfully", updatedRule)
+}
+
+func (app *App) DeleteTaxRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid tax rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.TaxRules.DeleteTaxRule(r.Context(), ruleID); err != nil {
+		utils.ErrorResponse(w, "Failed to delete tax rule", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Tax rule deleted successfully", nil)
+}
+
+func taxRuleFromInput(input models.TaxRuleInput) *models.TaxRule {
+	return &models.TaxRule{
+		Name:          input.Name,
+		Country:       input.Country,
+		Region:        input.Region,
+		Rate:          input.Rate,
+		CategoryRates: input.CategoryRates,
+		Inclusive:     input.Inclusive,
+	}
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+)
+
+const (
+	// maxWebhookBody caps the size of a webhook body.
+	maxWebhookBody = 64 << 10
+	// staleWebhookEvent is how long a received event may go without an
+	// outcome before it is taken to have been lost with its request.
+	staleWebhookEvent = 10 * time.Minute
+)
+
+// PaymentWebhook receives a provider's signed payment event. Events are stored
+// before they are applied, so a retry of a processed event is acknowledged
+// without changes, while a retry of one that failed or never finished is
+// applied again. A non-2xx answer tells the provider to retry.
+func (app *App) PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	provider, ok := app.PaymentProviders[mux.Vars(r)["provider"]]
+	if !ok {
+		utils.ErrorResponse(w, "Payment provider not found", http.StatusNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event, err := provider.ParseWebhook(payload, r.Header)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			utils.ErrorResponse(w, "Invalid webhook signature", http.StatusUnauthorized)
+			return
+		}
+		utils.ErrorResponse(w, "Invalid webhook event", http.StatusBadRequest)
+		return
+	}
+	if event.ID == "" {
+		utils.ErrorResponse(w, "Webhook event ID is required", http.StatusBadRequest)
+		return
+	}
+
+	record, err := app.WebhookEvents.CreateWebhookEvent(r.Context(), &models.WebhookEvent{
+		Provider:   provider.Name(),
+		Event:      *event,
+		Status:     models.WebhookEventReceived,
+		ReceivedAt: app.Clock.Now(),
+	})
+	if errors.Is(err, repository.ErrDuplicateWebhookEvent) {
+		record, err = app.WebhookEvents.GetWebhookEvent(r.Context(), provider.Name(), event.ID)
+		if err == nil && record == nil {
+			err = errors.New("duplicate webhook event not found")
+		}
+	}
+	if err != nil {
+		app.Logger.Println("Failed to store webhook event:", err)
+		utils.ErrorResponse(w, "Failed to store webhook event", http.StatusInternalServerError)
+		return
+	}
+
+	if record.Status == models.WebhookEventProcessed {
+		utils.SuccessResponse(w, "Webhook event already processed", nil)
+		return
+	}
+
+	if err := app.handleWebhookEvent(r.Context(), record); err != nil {
+		utils.ErrorResponse(w, "Failed to process webhook event", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, "Webhook event processed", nil)
+}
+
+// ReplayableWebhookEvents returns the stored events that failed, and those
+// received more than staleWebhookEvent ago whose request ended before
+// recording an outcome, oldest first.
+func (app *App) ReplayableWebhookEvents(ctx context.Context) ([]models.WebhookEvent, error) {
+	events, err := app.WebhookEvents.GetWebhookEventsByStatus(ctx, models.WebhookEventFailed)
+	if err != nil {
+		return nil, err
+	}
+	received, err := app.WebhookEvents.GetWebhookEventsByStatus(ctx, models.WebhookEventReceived)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := app.Clock.Now().Add(-staleWebhookEvent)
+	for _, event := range received {
+		if event.ReceivedAt.Before(cutoff) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].ReceivedAt.Before(events[j].ReceivedAt) })
+	return events, nil
+}
+
+// ReplayFailedWebhookEvents processes the events ReplayableWebhookEvents
+// returns again and returns how many succeeded this time.
+func (app *App) ReplayFailedWebhookEvents(ctx context.Context) (int, error) {
+	events, err := app.ReplayableWebhookEvents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for i := range events {
+		if err := app.handleWebhookEvent(ctx, &events[i]); err == nil {
+			replayed++
+		}
+	}
+	return replayed, nil
+}
+
+// handleWebhookEvent applies a stored event and records the outcome on it.
+func (app *App) handleWebhookEvent(ctx context.Context, record *models.WebhookEvent) error {
+	record.Attempts++
+	err := app.processPaymentEvent(ctx, record.Provider, &record.Event)
+	if err != nil {
+		app.Logger.Printf("Failed to process %s webhook event %s: %v", record.Provider, record.Event.ID, err)
+		record.Status = models.WebhookEventFailed
+		record.LastError = err.Error()
+	} else {
+		now := app.Clock.Now()
+		record.Status = models.WebhookEventProcessed
+		record.LastError = ""
+		record.ProcessedAt = &now
+	}
+
+	if updateErr := app.WebhookEvents.UpdateWebhookEvent(ctx, record); updateErr != nil {
+		app.Logger.Println("Failed to update webhook event:", updateErr)
+		if err == nil {
+			err = updateErr
+		}
+	}
+	return err
+}
+
+// processPaymentEvent brings the payment named by event, and its order, up to
+// date. Events carry running totals, so one that arrives late or twice leaves
+// the payment as it is; unknown event types are ignored. A failed or fully
+// refunded payment cancels its order.
+func (app *App) processPaymentEvent(ctx context.Context, provider string, event *models.PaymentEvent) error {
+	payment, err := app.Payments.GetPaymentByProviderRef(ctx, provider, event.Ref)
+	if err != nil {
+		return err
+	}
+	if payment == nil {
+		return fmt.Errorf("no payment with reference %q", event.Ref)
+	}
+
+	switch event.Type {
+	case models.PaymentEventAuthorized:
+		if payment.Status != models.PaymentPending {
+			return nil
+		}
+		_, err = app.transitionPayment(ctx, payment, models.PaymentAuthorized, nil)
+
+	case models.PaymentEventCaptured:
+		if !models.CanTransitionPayment(payment.Status, models.PaymentCaptured) || !payment.Captured.Less(event.Amount) {
+			return nil
+		}
+		if payment.Amount.Less(event.Amount) {
+			return fmt.Errorf("captured %s exceeds the payment amount %s", event.Amount, payment.Amount)
+		}
+		_, err = app.recordCapture(ctx, payment, event.Amount)
+
+	case models.PaymentEventFailed:
+		if models.CanTransitionPayment(payment.Status, models.PaymentFailed) {
+			if payment, err = app.recordFailure(ctx, payment, event.Reason); err != nil {
+				return err
+			}
+		}
+		if payment.Status == models.PaymentFailed {
+			err = app.cancelPaymentOrder(ctx, payment, "Payment failed")
+		}
+
+	case models.PaymentEventRefunded:
+		if payment.Status == models.PaymentCaptured && payment.Refunded.Less(event.Amount) {
+			refunded := event.Amount.Sub(payment.Refunded)
+			if payment.Refundable().Less(refunded) {
+				return fmt.Errorf("refunded %s exceeds the captured amount %s", event.Amount, payment.Captured)
+			}
+			if payment, err = app.recordRefund(ctx, payment, refunded); err != nil {
+				return err
+			}
+		}
+		if payment.Status == models.PaymentRefunded {
+			err = app.cancelPaymentOrder(ctx, payment, "Payment refunded")
+		}
+	}
+	return err
+}
+
+// cancelPaymentOrder cancels the order of a payment that failed or was
+// refunded in full, returning its stock and coupon like a cancellation by the
+// customer would. Orders that already shipped or were cancelled are left as
+// they are.
+func (app *App) cancelPaymentOrder(ctx context.Context, payment *models.Payment, reason string) error {
+	order, err := app.Orders.GetOrderByID(ctx, payment.OrderID)
+	if err != nil {
+		return err
+	}
+	if order == nil || !models.CanTransition(order.Status, models.OrderStatusCancelled) {
+		return nil
+	}
+
+	_, err = app.cancelOrder(ctx, order, "system", reason)
+	return err
+}
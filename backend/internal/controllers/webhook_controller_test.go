@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const webhookSecret = "whsec_test"
+
+func TestPaymentWebhookRejectsUnverifiedEvents(t *testing.T) {
+	provider := payments.NewFakeProvider(webhookSecret)
+	payload, header, err := provider.SignEvent(models.PaymentEvent{Type: models.PaymentEventCaptured, Ref: "pay_1", Amount: models.NewMoney(5000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, forgedHeader, err := payments.NewFakeProvider("other").SignEvent(models.PaymentEvent{Type: models.PaymentEventCaptured, Ref: "pay_1", Amount: models.NewMoney(5000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		provider   string
+		payload    []byte
+		header     http.Header
+		wantStatus int
+	}{
+		{name: "missing signature", provider: payments.FakeProviderName, payload: payload, header: http.Header{}, wantStatus: http.StatusUnauthorized},
+		{name: "signed with another secret", provider: payments.FakeProviderName, payload: forged, header: forgedHeader, wantStatus: http.StatusUnauthorized},
+		{name: "body changed after signing", provider: payments.FakeProviderName, payload: bytes.Replace(payload, []byte("5000"), []byte("9000"), 1), header: header, wantStatus: http.StatusUnauthorized},
+		{name: "unknown provider", provider: "other", payload: payload, header: header, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, payment := newWebhookApp(t)
+
+			w := postWebhook(app, tt.provider, tt.payload, tt.header)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			stored, err := app.Payments.GetPaymentByID(context.Background(), payment.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != models.PaymentAuthorized {
+				t.Errorf("payment status = %s, want %s", stored.Status, models.PaymentAuthorized)
+			}
+			for _, status := range []string{models.WebhookEventReceived, models.WebhookEventProcessed, models.WebhookEventFailed} {
+				events, err := app.WebhookEvents.GetWebhookEventsByStatus(context.Background(), status)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(events) != 0 {
+					t.Errorf("%d %s events stored, want none", len(events), status)
+				}
+			}
+		})
+	}
+}
+
+func TestPaymentWebhookDeduplicatesEvents(t *testing.T) {
+	ctx := context.Background()
+	app, payment := newWebhookApp(t)
+	provider := payments.NewFakeProvider(webhookSecret)
+
+	payload, header, err := provider.SignEvent(models.PaymentEvent{ID: "evt_1", Type: models.PaymentEventCaptured, Ref: payment.ProviderRef, Amount: payment.Amount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, wantMessage := range []string{"Webhook event processed", "Webhook event already processed"} {
+		w := postWebhook(app, payments.FakeProviderName, payload, header)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"`+wantMessage+`"`) {
+			t.Fatalf("delivery %d = %d %s, want %q", i+1, w.Code, w.Body, wantMessage)
+		}
+	}
+
+	record, err := app.WebhookEvents.GetWebhookEvent(ctx, payments.FakeProviderName, "evt_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != models.WebhookEventProcessed || record.Attempts != 1 {
+		t.Errorf("event = %s after %d attempts, want processed after 1", record.Status, record.Attempts)
+	}
+
+	order, err := app.Orders.GetOrderByID(ctx, payment.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.OrderStatusProcessing || len(order.StatusHistory) != 1 {
+		t.Errorf("order = %s with %d status changes, want processing with 1", order.Status, len(order.StatusHistory))
+	}
+}
+
+func TestPaymentWebhookRetriesFailedEvents(t *testing.T) {
+	ctx := context.Background()
+	app, payment := newWebhookApp(t)
+	provider := payments.NewFakeProvider(webhookSecret)
+
+	// The event arrives before the payment it names is stored
+	payload, header, err := provider.SignEvent(models.PaymentEvent{ID: "evt_1", Type: models.PaymentEventCaptured, Ref: "pay_2", Amount: payment.Amount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := postWebhook(app, payments.FakeProviderName, payload, header); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first delivery = %d, want %d: %s", w.Code, http.StatusInternalServerError, w.Body)
+	}
+
+	payment.ProviderRef = "pay_2"
+	if _, err := app.Payments.TransitionPayment(ctx, payment.Status, payment); err != nil {
+		t.Fatal(err)
+	}
+	if w := postWebhook(app, payments.FakeProviderName, payload, header); w.Code != http.StatusOK {
+		t.Fatalf("redelivery = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	record, err := app.WebhookEvents.GetWebhookEvent(ctx, payments.FakeProviderName, "evt_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != models.WebhookEventProcessed || record.Attempts != 2 || record.LastError != "" {
+		t.Errorf("event = %s after %d attempts (%q), want processed after 2", record.Status, record.Attempts, record.LastError)
+	}
+}
+
+// newWebhookApp returns an app with a pending order and its authorized
+// payment, whose provider reference is pay_1.
+func newWebhookApp(t *testing.T) (*App, *models.Payment) {
+	t.Helper()
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	app := NewApp(&config.Config{PaymentProvider: payments.FakeProviderName}, repos, log.New(io.Discard, "", 0), utils.SystemClock{}, payments.NewRegistry(payments.NewFakeProvider(webhookSecret)))
+
+	now := time.Now()
+	order, err := repos.Orders.CreateOrder(ctx, &models.Order{
+		UserID:     primitive.NewObjectID(),
+		Status:     models.OrderStatusPending,
+		TotalPrice: models.NewMoney(5000),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment, err := repos.Payments.CreatePayment(ctx, &models.Payment{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Provider:    payments.FakeProviderName,
+		ProviderRef: "pay_1",
+		Amount:      order.TotalPrice,
+		Status:      models.PaymentAuthorized,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app, payment
+}
+
+func postWebhook(app *App, provider string, payload []byte, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/payments/"+provider, bytes.NewReader(payload))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req = mux.SetURLVars(req, map[string]string{"provider": provider})
+	w := httptest.NewRecorder()
+	app.PaymentWebhook(w, req)
+	return w
+}
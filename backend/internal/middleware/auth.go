@@ -3,7 +3,6 @@ package middleware
 import (
 	"context"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,31 +16,34 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			utils.ErrorResponse(w, "Authorization header required", http.StatusUnauthorized)
-			return
+// AuthMiddleware returns a middleware that validates bearer tokens signed with secret.
+func AuthMiddleware(secret string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				utils.ErrorResponse(w, "Authorization header required", http.StatusUnauthorized)
+				return
+			}
+
+			tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+
+			claims := &Claims{}
+			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+				return []byte(secret), nil
+			})
+
+			if err != nil || !token.Valid {
+				utils.ErrorResponse(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			// Add user info to context
+			ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+			ctx = context.WithValue(ctx, "email", claims.Email)
+			ctx = context.WithValue(ctx, "role", claims.Role)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
 		}
-
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
-		if err != nil || !token.Valid {
-			utils.ErrorResponse(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
-
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		ctx = context.WithValue(ctx, "email", claims.Email)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// recordingWriter passes a response through while keeping a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Idempotency returns a middleware that makes requests carrying an
+// Idempotency-Key header safe to retry. The first request with a key runs and
+// its response is kept for ttl; a repeat from the same user gets that
+// response back with Idempotent-Replayed set instead of running again. A key
+// reused with a different method, path or body is rejected with 422, and one
+// whose first request is still running with 409. Responses with a 5xx status
+// are not kept, so the request can be retried with the same key. It must run
+// after AuthMiddleware; requests without a key or a user pass straight through.
+// Storage failures are reported to logger.
+func Idempotency(records repository.IdempotencyRepository, clock utils.Clock, ttl time.Duration, logger *log.Logger) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			userID, _ := r.Context().Value("userID").(string)
+			if key == "" || userID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				utils.ErrorResponse(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+			if err != nil {
+				utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := requestHash(r, body)
+			now := clock.Now()
+			record, reserved, err := reserveIdempotencyKey(r.Context(), records, &models.IdempotencyRecord{
+				UserID:      userID,
+				Key:         key,
+				RequestHash: hash,
+				Status:      models.IdempotencyInProgress,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+			}, now)
+			if err != nil {
+				logger.Println("Failed to reserve idempotency key:", err)
+				utils.ErrorResponse(w, "Failed to check Idempotency-Key", http.StatusInternalServerError)
+				return
+			}
+
+			if !reserved {
+				switch {
+				case record.RequestHash != hash:
+					utils.ErrorResponse(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+				case record.Status != models.IdempotencyCompleted:
+					utils.ErrorResponse(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+				default:
+					if record.ContentType != "" {
+						w.Header().Set("Content-Type", record.ContentType)
+					}
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(record.StatusCode)
+					w.Write(record.Body)
+				}
+				return
+			}
+
+			// Store the outcome even when the client went away mid-request
+			ctx := context.WithoutCancel(r.Context())
+
+			// A handler that panics leaves no outcome to store; release the
+			// key so the request can be retried
+			defer func() {
+				if p := recover(); p != nil {
+					if err := records.DeleteIdempotencyRecord(ctx, record.ID); err != nil {
+						logger.Println("Failed to release idempotency key:", err)
+					}
+					panic(p)
+				}
+			}()
+
+			rw := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			if rw.statusCode >= http.StatusInternalServerError {
+				err = records.DeleteIdempotencyRecord(ctx, record.ID)
+			} else {
+				record.Status = models.IdempotencyCompleted
+				record.StatusCode = rw.statusCode
+				record.ContentType = rw.Header().Get("Content-Type")
+				record.Body = rw.body.Bytes()
+				err = records.CompleteIdempotencyRecord(ctx, record)
+			}
+			if err != nil {
+				logger.Println("Failed to store idempotent response:", err)
+			}
+		}
+	}
+}
+
+// reserveIdempotencyKey stores record unless the user already holds its key,
+// in which case it returns the existing record and false. An expired record is
+// replaced.
+func reserveIdempotencyKey(ctx context.Context, records repository.IdempotencyRepository, record *models.IdempotencyRecord, now time.Time) (*models.IdempotencyRecord, bool, error) {
+	for range 3 {
+		created, err := records.CreateIdempotencyRecord(ctx, record, now)
+		if err == nil {
+			return created, true, nil
+		}
+		if !errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+			return nil, false, err
+		}
+
+		existing, err := records.GetIdempotencyRecord(ctx, record.UserID, record.Key, now)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, false, nil
+		}
+		// Released by its request, or expired, in the meantime
+	}
+	return nil, false, errors.New("idempotency key kept changing while it was reserved")
+}
+
+// requestHash fingerprints what a key was used for: the method, the path and
+// the body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
+)
+
+const idempotencyTTL = time.Hour
+
+type idempotentRequest struct {
+	path string
+	key  string
+	body string
+}
+
+func TestIdempotency(t *testing.T) {
+	first := idempotentRequest{path: "/api/orders", key: "key-1", body: `{"a":1}`}
+
+	tests := []struct {
+		name         string
+		firstStatus  int
+		elapsed      time.Duration
+		repeat       idempotentRequest
+		wantStatus   int
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{
+			name:         "repeat replays the first response",
+			firstStatus:  http.StatusCreated,
+			repeat:       first,
+			wantStatus:   http.StatusCreated,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:         "client errors are kept too",
+			firstStatus:  http.StatusBadRequest,
+			repeat:       first,
+			wantStatus:   http.StatusBadRequest,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:        "different body is rejected",
+			firstStatus: http.StatusCreated,
+			repeat:      idempotentRequest{path: first.path, key: first.key, body: `{"a":2}`},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantCalls:   1,
+		},
+		{
+			name:        "different path is rejected",
+			firstStatus: http.StatusCreated,
+			repeat:      idempotentRequest{path: "/api/cart", key: first.key, body: first.body},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantCalls:   1,
+		},
+		{
+			name:        "server errors are not kept",
+			firstStatus: http.StatusInternalServerError,
+			repeat:      first,
+			wantStatus:  http.StatusInternalServerError,
+			wantCalls:   2,
+		},
+		{
+			name:        "expired key runs again",
+			firstStatus: http.StatusCreated,
+			elapsed:     idempotencyTTL + time.Second,
+			repeat:      first,
+			wantStatus:  http.StatusCreated,
+			wantCalls:   2,
+		},
+		{
+			name:        "other key runs again",
+			firstStatus: http.StatusCreated,
+			repeat:      idempotentRequest{path: first.path, key: "key-2", body: first.body},
+			wantStatus:  http.StatusCreated,
+			wantCalls:   2,
+		},
+		{
+			name:        "no key runs again",
+			firstStatus: http.StatusCreated,
+			repeat:      idempotentRequest{path: first.path, body: first.body},
+			wantStatus:  http.StatusCreated,
+			wantCalls:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+			calls := 0
+			handler := idempotentHandler(clock, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				utils.ErrorResponse(w, "first", tt.firstStatus)
+			})
+
+			want := serveIdempotent(handler, first)
+			clock.now = clock.now.Add(tt.elapsed)
+			w := serveIdempotent(handler, tt.repeat)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if replayed := w.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %t, want %t", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && w.Body.String() != want.Body.String() {
+				t.Errorf("replayed body = %s, want %s", w.Body, want.Body)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyStillInUse(t *testing.T) {
+	req := idempotentRequest{path: "/api/orders", key: "key-1", body: `{"a":1}`}
+
+	var handler http.HandlerFunc
+	var repeat *httptest.ResponseRecorder
+	handler = idempotentHandler(&testClock{now: time.Now()}, func(w http.ResponseWriter, r *http.Request) {
+		if repeat == nil {
+			repeat = serveIdempotent(handler, req)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	serveIdempotent(handler, req)
+
+	if repeat.Code != http.StatusConflict {
+		t.Errorf("status = %d while the first request runs, want %d", repeat.Code, http.StatusConflict)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	req := idempotentRequest{path: "/api/orders", key: "key-1", body: `{"a":1}`}
+
+	panicked := false
+	handler := idempotentHandler(&testClock{now: time.Now()}, func(w http.ResponseWriter, r *http.Request) {
+		if !panicked {
+			panicked = true
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic was swallowed")
+			}
+		}()
+		serveIdempotent(handler, req)
+	}()
+
+	if w := serveIdempotent(handler, req); w.Code != http.StatusCreated {
+		t.Errorf("retry status = %d, want %d", w.Code, http.StatusCreated)
+	}
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func idempotentHandler(clock utils.Clock, next http.HandlerFunc) http.HandlerFunc {
+	records := repository.NewMemoryRepositories().IdempotencyKeys
+	return Idempotency(records, clock, idempotencyTTL, log.New(io.Discard, "", 0))(next)
+}
+
+func serveIdempotent(handler http.HandlerFunc, req idempotentRequest) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, req.path, strings.NewReader(req.body))
+	r = r.WithContext(context.WithValue(r.Context(), "userID", "user-1"))
+	if req.key != "" {
+		r.Header.Set(IdempotencyKeyHeader, req.key)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
@@ -0,0 +1,277 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+func IsValidInterval(interval string) bool {
+	switch interval {
+	case IntervalDay, IntervalWeek, IntervalMonth:
+		return true
+	}
+	return false
+}
+
+// TruncateToInterval returns the start of the day, week or month containing t,
+// in UTC. Weeks start on Monday.
+func TruncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch interval {
+	case IntervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case IntervalMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// NextInterval returns the start of the bucket following the one starting at t.
+func NextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// SalesQuery selects the non-cancelled orders created in [From, To) and the
+// bucket size to group them by.
+type SalesQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+}
+
+// BucketCount returns how many buckets NewSalesReport fills for the query,
+// without walking them.
+func (q SalesQuery) BucketCount() int {
+	if !q.From.Before(q.To) {
+		return 0
+	}
+	start, to := TruncateToInterval(q.From, q.Interval), q.To.UTC()
+	if q.Interval == IntervalMonth {
+		months := (to.Year()-start.Year())*12 + int(to.Month()) - int(start.Month())
+		if to.After(TruncateToInterval(to, IntervalMonth)) {
+			months++
+		}
+		return months
+	}
+
+	// Durations saturate at about 292 years, which is plenty for a cap
+	step := 24 * time.Hour
+	if q.Interval == IntervalWeek {
+		step *= 7
+	}
+	span := to.Sub(start)
+	buckets := int(span / step)
+	if span%step != 0 {
+		buckets++
+	}
+	return buckets
+}
+
+type SalesBucket struct {
+	Start             time.Time `json:"start" bson:"_id"`
+	Revenue           Money     `json:"revenue" bson:"-"`
+	Orders            int64     `json:"orders" bson:"orders"`
+	AverageOrderValue Money     `json:"averageOrderValue" bson:"-"`
+}
+
+type SalesReport struct {
+	Interval          string        `json:"interval"`
+	From              time.Time     `json:"from"`
+	To                time.Time     `json:"to"`
+	Revenue           Money         `json:"revenue"`
+	Orders            int64         `json:"orders"`
+	AverageOrderValue Money         `json:"averageOrderValue"`
+	Buckets           []SalesBucket `json:"buckets"`
+}
+
+// NewSalesReport lays the given buckets out on a continuous series covering the
+// query range, filling in empty buckets, and computes the totals and averages.
+func NewSalesReport(query SalesQuery, buckets []SalesBucket) *SalesReport {
+	byStart := make(map[time.Time]SalesBucket, len(buckets))
+	for _, b := range buckets {
+		byStart[b.Start.UTC()] = b
+	}
+
+	report := &SalesReport{
+		Interval: query.Interval,
+		From:     query.From,
+		To:       query.To,
+		Buckets:  []SalesBucket{},
+	}
+	for start := TruncateToInterval(query.From, query.Interval); start.Before(query.To); start = NextInterval(start, query.Interval) {
+		b := byStart[start]
+		b.Start = start
+		b.AverageOrderValue = b.Revenue.Div(b.Orders)
+		report.Buckets = append(report.Buckets, b)
+		report.Revenue = report.Revenue.Add(b.Revenue)
+		report.Orders += b.Orders
+	}
+	report.AverageOrderValue = report.Revenue.Div(report.Orders)
+
+	return report
+}
+
+const (
+	RankByUnits   = "units"
+	RankByRevenue = "revenue"
+)
+
+// UncategorizedCategory labels sales of products that have since been deleted.
+const UncategorizedCategory = "uncategorized"
+
+// ReportQuery selects the non-cancelled orders created in [From, To) and how
+// many rows ranked reports return.
+type ReportQuery struct {
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+type ProductSales struct {
+	ProductID primitive.ObjectID `json:"productID" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	Units     int64              `json:"units" bson:"units"`
+	Revenue   Money              `json:"revenue" bson:"-"`
+}
+
+type CategorySales struct {
+	Category string `json:"category" bson:"_id"`
+	Units    int64  `json:"units" bson:"units"`
+	Revenue  Money  `json:"revenue" bson:"-"`
+}
+
+// SellThrough compares the units of a product sold in the report range with
+// the stock it still has. Rate is sold / (sold + stock).
+type SellThrough struct {
+	ProductID primitive.ObjectID `json:"productID" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	UnitsSold int64              `json:"unitsSold" bson:"units"`
+	Stock     int64              `json:"stock" bson:"stock"`
+	Rate      float64            `json:"rate" bson:"rate"`
+}
+
+type SalesReports struct {
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	TopByUnits   []ProductSales  `json:"topByUnits"`
+	TopByRevenue []ProductSales  `json:"topByRevenue"`
+	Categories   []CategorySales `json:"categories"`
+	SellThrough  []SellThrough   `json:"sellThrough"`
+}
+
+// CustomerOrderStats summarises one customer's non-cancelled orders.
+type CustomerOrderStats struct {
+	UserID        primitive.ObjectID `json:"userID" bson:"_id"`
+	Orders        int64              `json:"orders" bson:"orders"`
+	TotalSpent    Money              `json:"totalSpent" bson:"-"`
+	FirstOrderAt  time.Time          `json:"firstOrderAt" bson:"firstOrderAt"`
+	SecondOrderAt *time.Time         `json:"secondOrderAt,omitempty" bson:"secondOrderAt,omitempty"`
+	LastOrderAt   time.Time          `json:"lastOrderAt" bson:"lastOrderAt"`
+}
+
+// CustomerValue is a customer's lifetime order stats with their account
+// details. Name and Email are empty when the account has been deleted.
+type CustomerValue struct {
+	CustomerOrderStats
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// SignupCohort counts the users who signed up in a month and how many of them
+// went on to place a first and a second order.
+type SignupCohort struct {
+	Month        string `json:"month"` // YYYY-MM, UTC
+	Signups      int64  `json:"signups"`
+	FirstOrders  int64  `json:"firstOrders"`
+	SecondOrders int64  `json:"secondOrders"`
+}
+
+type CustomerReport struct {
+	Customers          int64           `json:"customers"`
+	RepeatCustomers    int64           `json:"repeatCustomers"`
+	RepeatPurchaseRate float64         `json:"repeatPurchaseRate"`
+	TopCustomers       []CustomerValue `json:"topCustomers"`
+	Cohorts            []SignupCohort  `json:"cohorts"`
+}
+
+const (
+	RankBySpend  = "spend"
+	RankByOrders = "orders"
+)
+
+// NewCustomerReport joins the per-customer order stats with the user accounts,
+// ranks customers by spend or order count and builds the monthly signup
+// cohorts. Customers counts every user with at least one order.
+func NewCustomerReport(users []User, stats []CustomerOrderStats, rankBy string, limit int) *CustomerReport {
+	report := &CustomerReport{TopCustomers: []CustomerValue{}, Cohorts: []SignupCohort{}}
+
+	usersByID := make(map[primitive.ObjectID]User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	statsByUser := make(map[primitive.ObjectID]CustomerOrderStats, len(stats))
+	for _, s := range stats {
+		statsByUser[s.UserID] = s
+		report.Customers++
+		if s.Orders >= 2 {
+			report.RepeatCustomers++
+		}
+	}
+	if report.Customers > 0 {
+		report.RepeatPurchaseRate = float64(report.RepeatCustomers) / float64(report.Customers)
+	}
+
+	ranked := slices.Clone(stats)
+	slices.SortStableFunc(ranked, func(a, b CustomerOrderStats) int {
+		if rankBy == RankByOrders && a.Orders != b.Orders {
+			return cmp.Compare(b.Orders, a.Orders)
+		}
+		if a.TotalSpent.Amount != b.TotalSpent.Amount {
+			return cmp.Compare(b.TotalSpent.Amount, a.TotalSpent.Amount)
+		}
+		return cmp.Compare(b.Orders, a.Orders)
+	})
+	for _, s := range ranked[:min(len(ranked), limit)] {
+		u := usersByID[s.UserID]
+		report.TopCustomers = append(report.TopCustomers, CustomerValue{CustomerOrderStats: s, Name: u.Name, Email: u.Email})
+	}
+
+	cohorts := map[string]*SignupCohort{}
+	for _, u := range users {
+		month := u.CreatedAt.UTC().Format("2006-01")
+		if cohorts[month] == nil {
+			cohorts[month] = &SignupCohort{Month: month}
+		}
+		cohorts[month].Signups++
+		if s, ok := statsByUser[u.ID]; ok {
+			cohorts[month].FirstOrders++
+			if s.SecondOrderAt != nil {
+				cohorts[month].SecondOrders++
+			}
+		}
+	}
+	for _, c := range cohorts {
+		report.Cohorts = append(report.Cohorts, *c)
+	}
+	slices.SortFunc(report.Cohorts, func(a, b SignupCohort) int { return strings.Compare(a.Month, b.Month) })
+
+	return report
+}
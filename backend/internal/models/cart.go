@@ -6,22 +6,103 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// MaxLineQuantity is the most units of one variant a cart may hold.
+const MaxLineQuantity = 99
+
 type CartItem struct {
+	LineID    primitive.ObjectID `json:"lineID" bson:"lineID"`
 	ProductID primitive.ObjectID `json:"productID" bson:"productID"`
+	SKU       string             `json:"sku,omitempty" bson:"sku,omitempty"`
 	Quantity  int                `json:"quantity" bson:"quantity"`
 	Size      string             `json:"size" bson:"size"`
+	Color     string             `json:"color,omitempty" bson:"color,omitempty"`
 	AddedAt   time.Time          `json:"addedAt" bson:"addedAt"`
 }
 
 type Cart struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	UserID    primitive.ObjectID `json:"userID" bson:"userID"`
-	Products  []CartItem         `json:"products" bson:"products"`
-	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"userID" bson:"userID"`
+	Products   []CartItem         `json:"products" bson:"products"`
+	CouponCode string             `json:"couponCode,omitempty" bson:"couponCode,omitempty"`
+	UpdatedAt  time.Time          `json:"updatedAt" bson:"updatedAt"`
 }
 
 type AddToCartInput struct {
 	ProductID string `json:"productID"`
+	SKU       string `json:"sku"`
 	Quantity  int    `json:"quantity"`
 	Size      string `json:"size"`
+	Color     string `json:"color"`
+}
+
+// UpdateCartItemInput changes one cart line. Size and color are optional and
+// keep their current value when empty; a quantity of zero removes the line.
+type UpdateCartItemInput struct {
+	Quantity int    `json:"quantity"`
+	Size     string `json:"size"`
+	Color    string `json:"color"`
+}
+
+// SameVariant reports whether two cart items are the same product variant,
+// which share a cart line.
+func (i CartItem) SameVariant(other CartItem) bool {
+	return i.ProductID == other.ProductID && i.SKU == other.SKU && i.Size == other.Size && i.Color == other.Color
+}
+
+// VariantQuantity returns how many units of item's variant the cart holds on
+// lines other than item's own.
+func (c *Cart) VariantQuantity(item CartItem) int {
+	quantity := 0
+	for _, line := range c.Products {
+		if line.LineID != item.LineID && line.SameVariant(item) {
+			quantity += line.Quantity
+		}
+	}
+	return quantity
+}
+
+// FindLine returns the cart line with lineID, or nil.
+func (c *Cart) FindLine(lineID primitive.ObjectID) *CartItem {
+	for i := range c.Products {
+		if c.Products[i].LineID == lineID {
+			return &c.Products[i]
+		}
+	}
+	return nil
+}
+
+// PricedLine is a cart or order line priced at current product prices, with
+// the promotion discount it received, if any, and the tax charged on it.
+type PricedLine struct {
+	LineID    primitive.ObjectID `json:"lineID"`
+	ProductID primitive.ObjectID `json:"productID"`
+	SKU       string             `json:"sku,omitempty"`
+	Name      string             `json:"name"`
+	Category  string             `json:"category"`
+	UnitPrice Money              `json:"unitPrice"`
+	Quantity  int                `json:"quantity"`
+	Weight    int                `json:"weight,omitempty"` // grams per unit
+	Discount  Money              `json:"discount"`
+	Promotion *AppliedPromotion  `json:"promotion,omitempty"`
+	Tax       Money              `json:"tax"`
+	TaxRate   float64            `json:"taxRate"`
+}
+
+func (l PricedLine) Subtotal() Money {
+	return l.UnitPrice.Mul(l.Quantity)
+}
+
+// Net is the line subtotal less its promotion discount.
+func (l PricedLine) Net() Money {
+	return l.Subtotal().Sub(l.Discount)
+}
+
+// CartView is the cart as shown to the customer: every line priced, with
+// promotions and the applied coupon taken off, and the same totals checkout
+// will charge.
+type CartView struct {
+	*Cart
+	Lines       []PricedLine `json:"lines"`
+	CouponError string       `json:"couponError,omitempty"`
+	Totals
 }
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CouponTypePercent = "percent"
+	CouponTypeFixed   = "fixed"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponInactive      = errors.New("coupon is not active")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrCouponUsedUp        = errors.New("coupon usage limit reached")
+	ErrCouponUserLimit     = errors.New("you have already used this coupon the maximum number of times")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to any item in the cart")
+)
+
+// Coupon is a discount code taking Percent off, or a fixed Amount off, the
+// matching lines. Limits of zero mean unlimited. When Categories or ProductIDs
+// are set the discount only applies to matching lines.
+type Coupon struct {
+	ID            primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Code          string               `json:"code" bson:"code"`
+	Type          string               `json:"type" bson:"type"`                           // percent or fixed
+	Percent       float64              `json:"percent,omitempty" bson:"percent,omitempty"` // percent coupons
+	Amount        Money                `json:"amount,omitzero" bson:"amount,omitempty"`    // fixed coupons
+	MinOrderValue Money                `json:"minOrderValue" bson:"minOrderValue"`
+	ExpiresAt     *time.Time           `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	UsageLimit    int                  `json:"usageLimit" bson:"usageLimit"`
+	PerUserLimit  int                  `json:"perUserLimit" bson:"perUserLimit"`
+	UsedCount     int                  `json:"usedCount" bson:"usedCount"`
+	Categories    []string             `json:"categories,omitempty" bson:"categories,omitempty"`
+	ProductIDs    []primitive.ObjectID `json:"productIDs,omitempty" bson:"productIDs,omitempty"`
+	Active        bool                 `json:"active" bson:"active"`
+	CreatedAt     time.Time            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt" bson:"updatedAt"`
+}
+
+// CouponRedemption counts one user's uses of a coupon, towards its
+// PerUserLimit. There is at most one per coupon and user.
+type CouponRedemption struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CouponID  primitive.ObjectID `json:"couponID" bson:"couponID"`
+	UserID    primitive.ObjectID `json:"userID" bson:"userID"`
+	Count     int                `json:"count" bson:"count"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type CouponInput struct {
+	Code          string               `json:"code"`
+	Type          string               `json:"type"`
+	Percent       float64              `json:"percent"`
+	Amount        Money                `json:"amount"`
+	MinOrderValue Money                `json:"minOrderValue"`
+	ExpiresAt     *time.Time           `json:"expiresAt"`
+	UsageLimit    int                  `json:"usageLimit"`
+	PerUserLimit  int                  `json:"perUserLimit"`
+	Categories    []string             `json:"categories"`
+	ProductIDs    []primitive.ObjectID `json:"productIDs"`
+	Active        *bool                `json:"active"` // defaults to true
+}
+
+type ApplyCouponInput struct {
+	Code string `json:"code"`
+}
+
+// AppliedCoupon previews what a coupon takes off the current cart.
+type AppliedCoupon struct {
+	Code     string `json:"code"`
+	Discount Money  `json:"discount"`
+	Totals   Totals `json:"totals"`
+}
+
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Normalize upper-cases the code and checks the coupon's settings.
+func (c *Coupon) Normalize() error {
+	c.Code = NormalizeCouponCode(c.Code)
+	if c.Code == "" {
+		return errors.New("coupon code is required")
+	}
+
+	switch c.Type {
+	case CouponTypePercent:
+		if c.Percent <= 0 || c.Percent > 100 {
+			return errors.New("percentage coupons need a percent between 0 and 100")
+		}
+		c.Amount = Money{}
+	case CouponTypeFixed:
+		if err := c.Amount.Validate(); err != nil {
+			return err
+		}
+		if !c.Amount.IsPositive() {
+			return errors.New("fixed coupons need a positive amount")
+		}
+		c.Percent = 0
+	default:
+		return errors.New("coupon type must be percent or fixed")
+	}
+
+	if err := c.MinOrderValue.Validate(); err != nil {
+		return err
+	}
+	if c.UsageLimit < 0 || c.PerUserLimit < 0 {
+		return errors.New("usage limits cannot be negative")
+	}
+	return nil
+}
+
+// Apply checks that a customer who already used the coupon userUses times may
+// use it at now and returns the discount it gives on lines, net of their
+// promotion discounts. The usage limits checked here are advisory; the
+// repository enforces them atomically when the coupon is redeemed.
+func (c *Coupon) Apply(userUses int, now time.Time, lines []PricedLine) (Money, error) {
+	if !c.Active {
+		return Money{}, ErrCouponInactive
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return Money{}, ErrCouponExpired
+	}
+	if c.UsageLimit > 0 && c.UsedCount >= c.UsageLimit {
+		return Money{}, ErrCouponUsedUp
+	}
+	if c.PerUserLimit > 0 && userUses >= c.PerUserLimit {
+		return Money{}, ErrCouponUserLimit
+	}
+
+	var subtotal, eligible Money
+	for _, line := range lines {
+		subtotal = subtotal.Add(line.Net())
+		if c.appliesTo(line) {
+			eligible = eligible.Add(line.Net())
+		}
+	}
+	if subtotal.Less(c.MinOrderValue) {
+		return Money{}, fmt.Errorf("coupon requires a minimum order of %s", c.MinOrderValue)
+	}
+	if !eligible.IsPositive() {
+		return Money{}, ErrCouponNotApplicable
+	}
+
+	if c.Type == CouponTypePercent {
+		return eligible.Percent(c.Percent), nil
+	}
+	return c.Amount.Min(eligible), nil
+}
+
+// Allocate splits a discount given by Apply across the lines it was computed
+// on, in proportion to their net price. Rounding leftovers go to the last
+// eligible line, so the shares always add up to discount.
+func (c *Coupon) Allocate(lines []PricedLine, discount Money) []Money {
+	shares := make([]Money, len(lines))
+	var eligible Money
+	last := -1
+	for i, line := range lines {
+		if c.appliesTo(line) && line.Net().IsPositive() {
+			eligible = eligible.Add(line.Net())
+			last = i
+		}
+	}
+	if last < 0 {
+		return shares
+	}
+
+	remaining := discount
+	for i, line := range lines[:last] {
+		if c.appliesTo(line) && line.Net().IsPositive() {
+			shares[i] = NewMoney(discount.Amount * line.Net().Amount / eligible.Amount)
+			remaining = remaining.Sub(shares[i])
+		}
+	}
+	shares[last] = remaining
+	return shares
+}
+
+func (c *Coupon) appliesTo(line PricedLine) bool {
+	if len(c.Categories) == 0 && len(c.ProductIDs) == 0 {
+		return true
+	}
+	return slices.Contains(c.Categories, line.Category) || slices.Contains(c.ProductIDs, line.ProductID)
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCouponApply(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	lines := []PricedLine{
+		pricedLine("shirts", 2000, 2),
+		pricedLine("jackets", 5000, 1),
+	}
+	lines[1].Discount = NewMoney(1000) // a promotion already took 10.00 off
+
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		userUses int
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:   "percent off every line, net of promotions",
+			coupon: Coupon{Type: CouponTypePercent, Percent: 10, Active: true},
+			want:   800,
+		},
+		{
+			name:   "fixed amount",
+			coupon: Coupon{Type: CouponTypeFixed, Amount: NewMoney(1500), Active: true},
+			want:   1500,
+		},
+		{
+			name:   "fixed amount capped at the eligible lines",
+			coupon: Coupon{Type: CouponTypeFixed, Amount: NewMoney(10000), Categories: []string{"shirts"}, Active: true},
+			want:   4000,
+		},
+		{
+			name:   "percent off matching category only",
+			coupon: Coupon{Type: CouponTypePercent, Percent: 50, Categories: []string{"jackets"}, Active: true},
+			want:   2000,
+		},
+		{
+			name:    "inactive",
+			coupon:  Coupon{Type: CouponTypePercent, Percent: 10},
+			wantErr: ErrCouponInactive,
+		},
+		{
+			name:    "expired",
+			coupon:  Coupon{Type: CouponTypePercent, Percent: 10, ExpiresAt: &past, Active: true},
+			wantErr: ErrCouponExpired,
+		},
+		{
+			name:    "used up",
+			coupon:  Coupon{Type: CouponTypePercent, Percent: 10, UsageLimit: 5, UsedCount: 5, Active: true},
+			wantErr: ErrCouponUsedUp,
+		},
+		{
+			name:     "per-user limit reached",
+			coupon:   Coupon{Type: CouponTypePercent, Percent: 10, PerUserLimit: 1, Active: true},
+			userUses: 1,
+			wantErr:  ErrCouponUserLimit,
+		},
+		{
+			name:    "no matching line",
+			coupon:  Coupon{Type: CouponTypePercent, Percent: 10, Categories: []string{"pants"}, Active: true},
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "below the minimum order",
+			coupon:  Coupon{Type: CouponTypePercent, Percent: 10, MinOrderValue: NewMoney(10000), Active: true},
+			wantErr: errAny,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount, err := tt.coupon.Apply(tt.userUses, now, lines)
+			switch {
+			case tt.wantErr == errAny:
+				if err == nil {
+					t.Fatalf("Apply() = %s, want an error", discount)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("Apply() error = %v", err)
+			case discount.Amount != tt.want:
+				t.Errorf("Apply() = %d, want %d", discount.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestCouponAllocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		lines    []PricedLine
+		discount int64
+		want     []int64
+	}{
+		{
+			name:     "rounding leftover goes to the last line",
+			coupon:   Coupon{},
+			lines:    []PricedLine{pricedLine("a", 1000, 1), pricedLine("b", 1000, 1), pricedLine("c", 1000, 1)},
+			discount: 1000,
+			want:     []int64{333, 333, 334},
+		},
+		{
+			name:     "in proportion to net price",
+			coupon:   Coupon{},
+			lines:    []PricedLine{pricedLine("a", 3000, 1), pricedLine("b", 1000, 1)},
+			discount: 400,
+			want:     []int64{300, 100},
+		},
+		{
+			name:     "lines outside the coupon get nothing",
+			coupon:   Coupon{Categories: []string{"a"}},
+			lines:    []PricedLine{pricedLine("a", 1000, 1), pricedLine("b", 1000, 1), pricedLine("a", 2000, 1)},
+			discount: 100,
+			want:     []int64{33, 0, 67},
+		},
+		{
+			name:     "no eligible line",
+			coupon:   Coupon{Categories: []string{"z"}},
+			lines:    []PricedLine{pricedLine("a", 1000, 1)},
+			discount: 100,
+			want:     []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := tt.coupon.Allocate(tt.lines, NewMoney(tt.discount))
+			if len(shares) != len(tt.want) {
+				t.Fatalf("got %d shares, want %d", len(shares), len(tt.want))
+			}
+			for i, share := range shares {
+				if share.Amount != tt.want[i] {
+					t.Errorf("share %d = %d, want %d", i, share.Amount, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// errAny marks a test case that only expects some error.
+var errAny = errors.New("any error")
+
+func pricedLine(category string, unitPrice int64, quantity int) PricedLine {
+	return PricedLine{
+		LineID:    primitive.NewObjectID(),
+		ProductID: primitive.NewObjectID(),
+		Category:  category,
+		UnitPrice: NewMoney(unitPrice),
+		Quantity:  quantity,
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyRecord remembers the response to a request a user sent with an
+// Idempotency-Key header, so that a retry with the same key gets the same
+// response instead of repeating the request. RequestHash fingerprints the
+// method, path and body the key was first used with.
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      string             `json:"userID" bson:"userID"`
+	Key         string             `json:"key" bson:"key"`
+	RequestHash string             `json:"requestHash" bson:"requestHash"`
+	Status      string             `json:"status" bson:"status"`
+	StatusCode  int                `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	ContentType string             `json:"contentType,omitempty" bson:"contentType,omitempty"`
+	Body        []byte             `json:"body,omitempty" bson:"body,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiresAt   time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
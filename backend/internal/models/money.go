@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DefaultCurrency is the ISO 4217 code the store prices everything in.
+const DefaultCurrency = "USD"
+
+// MinorUnitsPerMajor is the number of minor units (cents) in one unit of
+// DefaultCurrency.
+const MinorUnitsPerMajor = 100
+
+// Money is an amount in the minor units of its currency, so totals add up
+// exactly. The store runs in a single currency: arithmetic keeps the
+// receiver's currency and inputs in any other currency are rejected by
+// Validate.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// NewMoney returns amount minor units of DefaultCurrency.
+func NewMoney(amount int64) Money {
+	return Money{Amount: amount, Currency: DefaultCurrency}
+}
+
+// MoneyFromMajor converts a decimal amount such as 19.99 to Money, rounding to
+// the nearest minor unit.
+func MoneyFromMajor(amount float64) Money {
+	return NewMoney(int64(math.Round(amount * MinorUnitsPerMajor)))
+}
+
+// Major returns the amount in major units, for display and ratios only.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / MinorUnitsPerMajor
+}
+
+func (m Money) currency(other Money) string {
+	if m.Currency != "" {
+		return m.Currency
+	}
+	if other.Currency != "" {
+		return other.Currency
+	}
+	return DefaultCurrency
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.currency(other)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.currency(other)}
+}
+
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.currency(m)}
+}
+
+// Percent returns percent of m, rounded half away from zero to a minor unit.
+func (m Money) Percent(percent float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * percent / 100)), Currency: m.currency(m)}
+}
+
+// Div splits m into n parts rounded to a minor unit, for averages. It returns
+// zero when n is zero.
+func (m Money) Div(n int64) Money {
+	if n == 0 {
+		return Money{Currency: m.currency(m)}
+	}
+	return Money{Amount: int64(math.Round(float64(m.Amount) / float64(n))), Currency: m.currency(m)}
+}
+
+// Min returns the smaller of m and other.
+func (m Money) Min(other Money) Money {
+	if other.Amount < m.Amount {
+		return Money{Amount: other.Amount, Currency: m.currency(other)}
+	}
+	return Money{Amount: m.Amount, Currency: m.currency(other)}
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Less reports whether m is smaller than other.
+func (m Money) Less(other Money) bool { return m.Amount < other.Amount }
+
+func (m Money) String() string {
+	return fmt.Sprintf("%.2f %s", m.Major(), m.currency(m))
+}
+
+// Validate checks that an amount given by a client is not negative and is in
+// the store's currency. An omitted currency means DefaultCurrency.
+func (m *Money) Validate() error {
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	if m.Currency != DefaultCurrency {
+		return fmt.Errorf("amounts must be in %s", DefaultCurrency)
+	}
+	if m.Amount < 0 {
+		return errors.New("amounts cannot be negative")
+	}
+	return nil
+}
+
+// MarshalJSON fills in DefaultCurrency for zero values built without one.
+func (m Money) MarshalJSON() ([]byte, error) {
+	type money Money
+	return json.Marshal(money{Amount: m.Amount, Currency: m.currency(m)})
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -8,10 +10,21 @@ import (
 
 type OrderItem struct {
 	ProductID primitive.ObjectID `json:"productID" bson:"productID"`
+	SKU       string             `json:"sku,omitempty" bson:"sku,omitempty"`
 	Name      string             `json:"name" bson:"name"`
-	Price     float64            `json:"price" bson:"price"`
+	Price     Money              `json:"price" bson:"price"`
 	Quantity  int                `json:"quantity" bson:"quantity"`
 	Size      string             `json:"size" bson:"size"`
+	Color     string             `json:"color,omitempty" bson:"color,omitempty"`
+	Discount  Money              `json:"discount,omitzero" bson:"discount,omitempty"` // promotion discount on the whole line
+	Promotion *AppliedPromotion  `json:"promotion,omitempty" bson:"promotion,omitempty"`
+	Tax       Money              `json:"tax,omitzero" bson:"tax,omitempty"` // tax on the line after all discounts
+	TaxRate   float64            `json:"taxRate,omitempty" bson:"taxRate,omitempty"`
+}
+
+// Total is the line's price times quantity, less its promotion discount.
+func (i OrderItem) Total() Money {
+	return i.Price.Mul(i.Quantity).Sub(i.Discount)
 }
 
 type Address struct {
@@ -19,19 +32,146 @@ type Address struct {
 	City       string `json:"city" bson:"city"`
 	PostalCode string `json:"postalCode" bson:"postalCode"`
 	Country    string `json:"country" bson:"country"`
+	Region     string `json:"region,omitempty" bson:"region,omitempty"` // state or province, for tax
+}
+
+// AddressCode normalises a country or region code for storage and lookup.
+func AddressCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PostalCode normalises a postal code or postal-code prefix for matching:
+// upper case, without spaces.
+func PostalCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
+
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+)
+
+// orderTransitions lists the statuses each status may move to. Delivered and
+// cancelled orders are final.
+var orderTransitions = map[string][]string{
+	OrderStatusPending:    {OrderStatusProcessing, OrderStatusCancelled},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:    {OrderStatusDelivered},
+}
+
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusProcessing, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether an order may move from one status to another.
+func CanTransition(from, to string) bool {
+	return slices.Contains(orderTransitions[from], to)
+}
+
+// StatusChange records one step in an order's status history.
+type StatusChange struct {
+	From   string    `json:"from,omitempty" bson:"from,omitempty"`
+	Status string    `json:"status" bson:"status"`
+	At     time.Time `json:"at" bson:"at"`
+	Actor  string    `json:"actor" bson:"actor"` // user ID of whoever made the change, or "system"
+	Note   string    `json:"note,omitempty" bson:"note,omitempty"`
 }
 
 type Order struct {
-	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	UserID          primitive.ObjectID `json:"userID" bson:"userID"`
-	Products        []OrderItem        `json:"products" bson:"products"`
-	TotalPrice      float64            `json:"totalPrice" bson:"totalPrice"`
-	Status          string             `json:"status" bson:"status"` // pending, processing, shipped, delivered, cancelled
-	ShippingAddress Address            `json:"shippingAddress" bson:"shippingAddress"`
-	CreatedAt       time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt       time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID            primitive.ObjectID `json:"userID" bson:"userID"`
+	Products          []OrderItem        `json:"products" bson:"products"`
+	Subtotal          Money              `json:"subtotal" bson:"subtotal"`
+	PromotionDiscount Money              `json:"promotionDiscount" bson:"promotionDiscount"`
+	CouponCode        string             `json:"couponCode,omitempty" bson:"couponCode,omitempty"`
+	CouponID          primitive.ObjectID `json:"couponID,omitzero" bson:"couponID,omitempty"`
+	Discount          Money              `json:"discount" bson:"discount"` // coupon discount
+	ShippingMethod    string             `json:"shippingMethod,omitempty" bson:"shippingMethod,omitempty"`
+	Shipping          Money              `json:"shipping" bson:"shipping"`
+	Tax               Money              `json:"tax" bson:"tax"`
+	TaxInclusive      bool               `json:"taxInclusive,omitempty" bson:"taxInclusive,omitempty"`
+	TotalPrice        Money              `json:"totalPrice" bson:"totalPrice"` // grand total
+	Status            string             `json:"status" bson:"status"`         // pending, processing, shipped, delivered, cancelled
+	StatusHistory     []StatusChange     `json:"statusHistory" bson:"statusHistory"`
+	CancelReason      string             `json:"cancelReason,omitempty" bson:"cancelReason,omitempty"`
+	ShippingAddress   Address            `json:"shippingAddress" bson:"shippingAddress"`
+	CreatedAt         time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt         time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// SetTotals stores the price breakdown checkout charged.
+func (o *Order) SetTotals(t Totals) {
+	o.Subtotal = t.Subtotal
+	o.PromotionDiscount = t.PromotionDiscount
+	o.Discount = t.CouponDiscount
+	o.Shipping = t.Shipping
+	o.Tax = t.Tax
+	o.TaxInclusive = t.TaxInclusive
+	o.TotalPrice = t.Total
+}
+
+// OrderItemDetail is an order line with its total, as returned by the order
+// detail endpoint.
+type OrderItemDetail struct {
+	OrderItem
+	LineTotal Money `json:"lineTotal"`
+}
+
+// OrderDetail is the single-order view. Its Products field shadows the
+// embedded order's lines so each line carries its total.
+type OrderDetail struct {
+	*Order
+	Products []OrderItemDetail `json:"products"`
+}
+
+func NewOrderDetail(order *Order) *OrderDetail {
+	items := make([]OrderItemDetail, len(order.Products))
+	for i, item := range order.Products {
+		items[i] = OrderItemDetail{OrderItem: item, LineTotal: item.Total()}
+	}
+	return &OrderDetail{Order: order, Products: items}
+}
+
+// OrderQuery holds the admin order filters. Nil and zero fields are ignored.
+type OrderQuery struct {
+	Status   string
+	UserID   *primitive.ObjectID
+	From     *time.Time // inclusive lower bound on createdAt
+	To       *time.Time // exclusive upper bound on createdAt
+	MinTotal *Money
+	MaxTotal *Money
+	Sort     string // field name, prefixed with "-" for descending order
+	Page     int
+	Limit    int
+}
+
+type OrderPage struct {
+	Items      []Order `json:"items"`
+	Total      int64   `json:"total"`
+	Page       int     `json:"page"`
+	Limit      int     `json:"limit"`
+	TotalPages int     `json:"totalPages"`
+	Next       string  `json:"next,omitempty"`
+	Prev       string  `json:"prev,omitempty"`
+}
+
+type UpdateOrderStatusInput struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type CancelOrderInput struct {
+	Reason string `json:"reason"`
 }
 
 type CreateOrderInput struct {
 	ShippingAddress Address `json:"shippingAddress"`
+	ShippingMethod  string  `json:"shippingMethod"` // required when a shipping zone covers the address
 }
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PaymentPending    = "pending"    // waiting for the customer to confirm with the provider
+	PaymentAuthorized = "authorized" // funds are held and can be captured
+	PaymentCaptured   = "captured"
+	PaymentRefunded   = "refunded" // fully refunded
+	PaymentFailed     = "failed"
+	PaymentCancelled  = "cancelled" // the order was cancelled before capture
+)
+
+// paymentTransitions lists the statuses each payment status may move to.
+// Refunded, failed and cancelled payments are final.
+var paymentTransitions = map[string][]string{
+	PaymentPending:    {PaymentPending, PaymentAuthorized, PaymentCaptured, PaymentFailed, PaymentCancelled}, // stays pending when the provider's reference is set
+	PaymentAuthorized: {PaymentCaptured, PaymentFailed, PaymentCancelled},
+	PaymentCaptured:   {PaymentCaptured, PaymentRefunded}, // partial refunds stay captured
+}
+
+// CanTransitionPayment reports whether a payment may move from one status to
+// another.
+func CanTransitionPayment(from, to string) bool {
+	return slices.Contains(paymentTransitions[from], to)
+}
+
+// Payment is the money collected for one order through a payment provider.
+// ProviderRef is the provider's own ID for it, empty until the provider has
+// been asked to authorize the payment.
+type Payment struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID       primitive.ObjectID `json:"orderID" bson:"orderID"`
+	UserID        primitive.ObjectID `json:"userID" bson:"userID"`
+	Provider      string             `json:"provider" bson:"provider"`
+	ProviderRef   string             `json:"providerRef,omitempty" bson:"providerRef,omitempty"`
+	ClientSecret  string             `json:"-" bson:"clientSecret,omitempty"`
+	Amount        Money              `json:"amount" bson:"amount"`
+	Captured      Money              `json:"captured,omitzero" bson:"captured,omitempty"`
+	Refunded      Money              `json:"refunded,omitzero" bson:"refunded,omitempty"`
+	Status        string             `json:"status" bson:"status"`
+	FailureReason string             `json:"failureReason,omitempty" bson:"failureReason,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// PaymentIntent is what the client needs to complete a payment with the
+// provider.
+type PaymentIntent struct {
+	PaymentID    primitive.ObjectID `json:"paymentID"`
+	Provider     string             `json:"provider"`
+	ClientSecret string             `json:"clientSecret,omitempty"`
+	Amount       Money              `json:"amount"`
+	Status       string             `json:"status"`
+}
+
+func (p *Payment) Intent() *PaymentIntent {
+	return &PaymentIntent{
+		PaymentID:    p.ID,
+		Provider:     p.Provider,
+		ClientSecret: p.ClientSecret,
+		Amount:       p.Amount,
+		Status:       p.Status,
+	}
+}
+
+// Refundable is the captured amount not refunded yet.
+func (p *Payment) Refundable() Money {
+	return p.Captured.Sub(p.Refunded)
+}
+
+// Event types reported by payment provider webhooks.
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventFailed     = "payment.failed"
+	PaymentEventRefunded   = "payment.refunded"
+)
+
+// PaymentEvent is a provider's notification about the payment with reference
+// Ref. Amount is the total captured so far for capture events and the total
+// refunded so far for refund events, so a repeated event changes nothing.
+type PaymentEvent struct {
+	ID     string `json:"id" bson:"id"` // the provider's event ID
+	Type   string `json:"type" bson:"type"`
+	Ref    string `json:"ref" bson:"ref"`
+	Amount Money  `json:"amount" bson:"amount"`
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"` // why a payment failed
+}
+
+const (
+	WebhookEventReceived  = "received"
+	WebhookEventProcessed = "processed"
+	WebhookEventFailed    = "failed"
+)
+
+// WebhookEvent records a verified webhook so provider retries can be
+// recognised and failed events replayed.
+type WebhookEvent struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Provider    string             `json:"provider" bson:"provider"`
+	Event       PaymentEvent       `json:"event" bson:"event"`
+	Status      string             `json:"status" bson:"status"`
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	LastError   string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	ReceivedAt  time.Time          `json:"receivedAt" bson:"receivedAt"`
+	ProcessedAt *time.Time         `json:"processedAt,omitempty" bson:"processedAt,omitempty"`
+}
+
+// CheckoutResult is the order created at checkout with the payment the client
+// has to complete.
+type CheckoutResult struct {
+	*Order
+	Payment *PaymentIntent `json:"payment"`
+}
+
+type RefundInput struct {
+	Amount *Money `json:"amount"` // defaults to everything still refundable
+}
@@ -0,0 +1,58 @@
+package models
+
+// Totals is the price breakdown of a cart or an order. Promotion discounts are
+// taken off individual lines; the coupon discount applies to the order as a
+// whole.
+type Totals struct {
+	Subtotal          Money `json:"subtotal"`          // every line at its unit price
+	PromotionDiscount Money `json:"promotionDiscount"` // sum of the line discounts
+	CouponDiscount    Money `json:"couponDiscount"`
+	Shipping          Money `json:"shipping"`
+	Tax               Money `json:"tax"`
+	TaxInclusive      bool  `json:"taxInclusive"` // Tax is already part of the prices
+	Total             Money `json:"total"`
+}
+
+// NewTotals adds up the subtotal and promotion discounts of lines. The other
+// amounts start at zero.
+func NewTotals(lines []PricedLine) Totals {
+	t := Totals{
+		Subtotal:          NewMoney(0),
+		PromotionDiscount: NewMoney(0),
+		CouponDiscount:    NewMoney(0),
+		Shipping:          NewMoney(0),
+		Tax:               NewMoney(0),
+	}
+	for _, line := range lines {
+		t.Subtotal = t.Subtotal.Add(line.Subtotal())
+		t.PromotionDiscount = t.PromotionDiscount.Add(line.Discount)
+	}
+	t.Sum()
+	return t
+}
+
+// DiscountedSubtotal is the value of the goods after promotions and the
+// coupon, which free-shipping thresholds are measured against.
+func (t *Totals) DiscountedSubtotal() Money {
+	return t.Subtotal.Sub(t.PromotionDiscount).Sub(t.CouponDiscount)
+}
+
+// Sum recomputes Total from the other amounts. Inclusive tax is not added
+// again.
+func (t *Totals) Sum() {
+	t.Total = t.DiscountedSubtotal().Add(t.Shipping)
+	if !t.TaxInclusive {
+		t.Total = t.Total.Add(t.Tax)
+	}
+}
+
+// Quote is a cart priced for checkout: its lines with their discounts, the
+// coupon and shipping method that applied, if any, and the totals.
+// CouponError explains why a requested coupon was left out.
+type Quote struct {
+	Lines       []PricedLine
+	Coupon      *Coupon
+	CouponError error
+	Shipping    *ShippingQuote
+	Totals      Totals
+}
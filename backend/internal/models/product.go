@@ -1,6 +1,10 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,21 +14,291 @@ type Product struct {
 	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Name        string             `json:"name" bson:"name"`
 	Description string             `json:"description" bson:"description"`
-	Price       float64            `json:"price" bson:"price"`
+	Price       Money              `json:"price" bson:"price"` // regular price, shown struck through during a sale
 	Size        []string           `json:"size" bson:"size"`
 	Category    string             `json:"category" bson:"category"`
 	ImageURL    string             `json:"imageURL" bson:"imageURL"`
 	Stock       int                `json:"stock" bson:"stock"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	Weight      int                `json:"weight,omitempty" bson:"weight,omitempty"` // grams per unit, for shipping rates
+	Variants    []ProductVariant   `json:"variants,omitempty" bson:"variants,omitempty"`
+
+	SalePrice    *Money     `json:"salePrice,omitempty" bson:"salePrice,omitempty"`
+	SaleStartsAt *time.Time `json:"saleStartsAt,omitempty" bson:"saleStartsAt,omitempty"`
+	SaleEndsAt   *time.Time `json:"saleEndsAt,omitempty" bson:"saleEndsAt,omitempty"`
+
+	// EffectivePrice and OnSale are derived at read time by SetEffectivePrice
+	// and never stored.
+	EffectivePrice Money `json:"effectivePrice" bson:"-"`
+	OnSale         bool  `json:"onSale" bson:"-"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ProductVariant is one purchasable size/color combination of a product. When a
+// product has variants, stock is tracked per variant and Product.Stock and
+// Product.Size summarize them.
+type ProductVariant struct {
+	SKU   string `json:"sku" bson:"sku"`
+	Size  string `json:"size" bson:"size"`
+	Color string `json:"color,omitempty" bson:"color,omitempty"`
+	Stock int    `json:"stock" bson:"stock"`
+	Price *Money `json:"price,omitempty" bson:"price,omitempty"` // overrides Product.Price when set
+
+	// EffectivePrice is what the variant costs now, derived at read time by
+	// Product.SetEffectivePrice and never stored.
+	EffectivePrice Money `json:"effectivePrice" bson:"-"`
 }
 
 type ProductInput struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
-	Price       float64  `json:"price"`
+	Price       Money    `json:"price"`
 	Size        []string `json:"size"`
 	Category    string   `json:"category"`
 	ImageURL    string   `json:"imageURL"`
 	Stock       int      `json:"stock"`
+	Weight      int      `json:"weight"`
+
+	Variants []ProductVariant `json:"variants"`
+
+	SalePrice    *Money     `json:"salePrice"`
+	SaleStartsAt *time.Time `json:"saleStartsAt"`
+	SaleEndsAt   *time.Time `json:"saleEndsAt"`
+}
+
+// NormalizeVariants validates the product's variants, assigns SKUs to those
+// without one and refreshes the Size and Stock summaries. Products without
+// variants are left untouched.
+func (p *Product) NormalizeVariants() error {
+	if len(p.Variants) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	sizes := []string{}
+	stock := 0
+
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		if v.Size == "" {
+			return errors.New("every variant needs a size")
+		}
+		if v.Stock < 0 {
+			return errors.New("variant stock cannot be negative")
+		}
+		if v.Price != nil {
+			if err := v.Price.Validate(); err != nil {
+				return err
+			}
+			if !v.Price.IsPositive() {
+				return errors.New("variant price must be positive")
+			}
+		}
+		if v.SKU == "" {
+			v.SKU = p.defaultSKU(v.Size, v.Color)
+		}
+		if seen[v.SKU] {
+			return fmt.Errorf("duplicate variant SKU %q", v.SKU)
+		}
+		seen[v.SKU] = true
+
+		if !slices.Contains(sizes, v.Size) {
+			sizes = append(sizes, v.Size)
+		}
+		stock += v.Stock
+	}
+
+	p.Size = sizes
+	p.Stock = stock
+	return nil
+}
+
+func (p *Product) defaultSKU(size, color string) string {
+	parts := []string{strings.ToUpper(p.ID.Hex()[16:]), size}
+	if color != "" {
+		parts = append(parts, color)
+	}
+	return strings.ToUpper(strings.Join(parts, "-"))
+}
+
+// ResolveVariant finds the variant a shopper picked, by SKU when given and
+// otherwise by size and color. Color may be omitted when the size comes in a
+// single color. It returns nil when nothing matches.
+func (p *Product) ResolveVariant(sku, size, color string) *ProductVariant {
+	if sku != "" {
+		for i := range p.Variants {
+			if p.Variants[i].SKU == sku {
+				return &p.Variants[i]
+			}
+		}
+		return nil
+	}
+
+	var match *ProductVariant
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		if v.Size != size || (color != "" && v.Color != color) {
+			continue
+		}
+		if match != nil {
+			// Ambiguous: the size exists in several colors
+			return nil
+		}
+		match = v
+	}
+	return match
+}
+
+// OffersSize reports whether a product without variants can be bought in size.
+// Products that list no sizes accept only an empty size.
+func (p *Product) OffersSize(size string) bool {
+	if len(p.Size) == 0 {
+		return size == ""
+	}
+	return slices.Contains(p.Size, size)
+}
+
+// ValidateSale checks that a sale price is positive, below the regular price
+// and every variant's own price, and scheduled to end after it starts.
+func (p *Product) ValidateSale() error {
+	if p.SalePrice == nil {
+		return nil
+	}
+	if err := p.SalePrice.Validate(); err != nil {
+		return err
+	}
+	if !p.SalePrice.IsPositive() || !p.SalePrice.Less(p.Price) {
+		return errors.New("sale price must be positive and below the regular price")
+	}
+	for _, v := range p.Variants {
+		if v.Price != nil && !p.SalePrice.Less(*v.Price) {
+			return errors.New("sale price must be below the price of every variant")
+		}
+	}
+	if p.SaleStartsAt != nil && p.SaleEndsAt != nil && !p.SaleStartsAt.Before(*p.SaleEndsAt) {
+		return errors.New("sale must start before it ends")
+	}
+	return nil
+}
+
+// SaleActive reports whether the sale price applies at now. Sales revert on
+// their own once SaleEndsAt passes.
+func (p *Product) SaleActive(now time.Time) bool {
+	return p.SalePrice != nil &&
+		(p.SaleStartsAt == nil || !now.Before(*p.SaleStartsAt)) &&
+		(p.SaleEndsAt == nil || now.Before(*p.SaleEndsAt))
+}
+
+// PriceAt returns the product's price at now: the sale price while the sale
+// runs, the regular price otherwise.
+func (p *Product) PriceAt(now time.Time) Money {
+	if p.SaleActive(now) {
+		return *p.SalePrice
+	}
+	return p.Price
+}
+
+// SetEffectivePrice fills in the derived EffectivePrice and OnSale fields of
+// the product and its variants.
+func (p *Product) SetEffectivePrice(now time.Time) {
+	p.EffectivePrice = p.PriceAt(now)
+	p.OnSale = p.SaleActive(now)
+	for i := range p.Variants {
+		p.Variants[i].EffectivePrice = p.VariantPrice(&p.Variants[i], now)
+	}
+}
+
+// VariantPrice returns the price charged for v at now: the product's current
+// price, or the variant's own price when it has one, except that a running
+// sale covers every variant whose own price is not already lower.
+func (p *Product) VariantPrice(v *ProductVariant, now time.Time) Money {
+	if v == nil || v.Price == nil {
+		return p.PriceAt(now)
+	}
+	if p.SaleActive(now) {
+		return p.SalePrice.Min(*v.Price)
+	}
+	return *v.Price
+}
+
+// FromPriceAt returns the lowest price the product sells for at now: the
+// cheapest variant's when it has variants, PriceAt otherwise. Listings filter,
+// sort and bucket products by it.
+func (p *Product) FromPriceAt(now time.Time) Money {
+	if len(p.Variants) == 0 {
+		return p.PriceAt(now)
+	}
+
+	price := p.VariantPrice(&p.Variants[0], now)
+	for i := range p.Variants[1:] {
+		price = price.Min(p.VariantPrice(&p.Variants[i+1], now))
+	}
+	return price
+}
+
+// ProductQuery describes a filtered, sorted page of the product catalog.
+// Nil pointer fields are not applied.
+type ProductQuery struct {
+	Category string
+	Size     string
+	MinPrice *Money // price filters and sorting use FromPriceAt(Now)
+	MaxPrice *Money
+	InStock  *bool
+	Sort     string // field name, prefixed with "-" for descending order
+	Page     int
+	Limit    int
+	Now      time.Time
+}
+
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	Total      int64     `json:"total"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	TotalPages int       `json:"totalPages"`
+	Next       string    `json:"next,omitempty"`
+	Prev       string    `json:"prev,omitempty"`
+
+	Facets *ProductFacets `json:"facets,omitempty"`
+}
+
+type FacetCount struct {
+	Value string `json:"value" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// PriceBucket counts products priced in [Min, Max). Max is nil for the open
+// ended top bucket.
+type PriceBucket struct {
+	Min   Money  `json:"min"`
+	Max   *Money `json:"max,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// ProductFacets holds the sidebar counts for a product listing, computed over
+// the products that match the listing's filters.
+type ProductFacets struct {
+	Categories   []FacetCount  `json:"categories"`
+	Sizes        []FacetCount  `json:"sizes"`
+	PriceBuckets []PriceBucket `json:"priceBuckets"`
+	InStock      int64         `json:"inStock"`
+	OutOfStock   int64         `json:"outOfStock"`
+}
+
+// PriceBucketBounds are the lower bounds of the price facet buckets, in minor
+// units of DefaultCurrency.
+var PriceBucketBounds = []int64{0, 2500, 5000, 10000, 20000}
+
+// NewPriceBuckets returns one empty bucket per entry in PriceBucketBounds.
+func NewPriceBuckets() []PriceBucket {
+	buckets := make([]PriceBucket, len(PriceBucketBounds))
+	for i, lower := range PriceBucketBounds {
+		buckets[i].Min = NewMoney(lower)
+		if i+1 < len(PriceBucketBounds) {
+			upper := NewMoney(PriceBucketBounds[i+1])
+			buckets[i].Max = &upper
+		}
+	}
+	return buckets
 }
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PromotionPercentOff = "percent_off" // Percent off every matching line
+	PromotionBuyXGetY   = "buy_x_get_y" // for every BuyQuantity matching units, FreeQuantity more are free
+	PromotionFreeItem   = "free_item"   // one unit of FreeProductID is free
+)
+
+// Promotion is an automatic discount rule. It is live while Active and inside
+// its optional StartsAt/EndsAt window, and only applies once the cart subtotal
+// reaches MinSubtotal. Categories and ProductIDs narrow the lines it applies
+// to; when both are empty it applies to every line.
+type Promotion struct {
+	ID            primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Name          string               `json:"name" bson:"name"`
+	Type          string               `json:"type" bson:"type"`
+	Priority      int                  `json:"priority" bson:"priority"` // higher runs first
+	Active        bool                 `json:"active" bson:"active"`
+	StartsAt      *time.Time           `json:"startsAt,omitempty" bson:"startsAt,omitempty"`
+	EndsAt        *time.Time           `json:"endsAt,omitempty" bson:"endsAt,omitempty"`
+	MinSubtotal   Money                `json:"minSubtotal" bson:"minSubtotal"`
+	Categories    []string             `json:"categories,omitempty" bson:"categories,omitempty"`
+	ProductIDs    []primitive.ObjectID `json:"productIDs,omitempty" bson:"productIDs,omitempty"`
+	Percent       float64              `json:"percent,omitempty" bson:"percent,omitempty"`
+	BuyQuantity   int                  `json:"buyQuantity,omitempty" bson:"buyQuantity,omitempty"`
+	FreeQuantity  int                  `json:"freeQuantity,omitempty" bson:"freeQuantity,omitempty"`
+	FreeProductID *primitive.ObjectID  `json:"freeProductID,omitempty" bson:"freeProductID,omitempty"`
+	CreatedAt     time.Time            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt" bson:"updatedAt"`
+}
+
+type PromotionInput struct {
+	Name          string               `json:"name"`
+	Type          string               `json:"type"`
+	Priority      int                  `json:"priority"`
+	Active        *bool                `json:"active"` // defaults to true
+	StartsAt      *time.Time           `json:"startsAt"`
+	EndsAt        *time.Time           `json:"endsAt"`
+	MinSubtotal   Money                `json:"minSubtotal"`
+	Categories    []string             `json:"categories"`
+	ProductIDs    []primitive.ObjectID `json:"productIDs"`
+	Percent       float64              `json:"percent"`
+	BuyQuantity   int                  `json:"buyQuantity"`
+	FreeQuantity  int                  `json:"freeQuantity"`
+	FreeProductID *primitive.ObjectID  `json:"freeProductID"`
+}
+
+// AppliedPromotion names the promotion that discounted a line.
+type AppliedPromotion struct {
+	ID   primitive.ObjectID `json:"id" bson:"id"`
+	Name string             `json:"name" bson:"name"`
+}
+
+// Validate checks that the promotion's settings fit its type.
+func (p *Promotion) Validate() error {
+	if p.Name == "" {
+		return errors.New("promotion name is required")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.StartsAt.Before(*p.EndsAt) {
+		return errors.New("promotion must start before it ends")
+	}
+	if err := p.MinSubtotal.Validate(); err != nil {
+		return err
+	}
+
+	switch p.Type {
+	case PromotionPercentOff:
+		if p.Percent <= 0 || p.Percent > 100 {
+			return errors.New("percent must be between 0 and 100")
+		}
+	case PromotionBuyXGetY:
+		if p.BuyQuantity < 1 || p.FreeQuantity < 1 {
+			return errors.New("buy and free quantities must be at least 1")
+		}
+	case PromotionFreeItem:
+		if p.FreeProductID == nil {
+			return errors.New("free item promotions need a free product")
+		}
+	default:
+		return errors.New("promotion type must be percent_off, buy_x_get_y or free_item")
+	}
+	return nil
+}
+
+// IsLive reports whether the promotion is active and scheduled at now.
+func (p *Promotion) IsLive(now time.Time) bool {
+	return p.Active &&
+		(p.StartsAt == nil || !now.Before(*p.StartsAt)) &&
+		(p.EndsAt == nil || now.Before(*p.EndsAt))
+}
+
+func (p *Promotion) appliesTo(line PricedLine) bool {
+	if len(p.Categories) == 0 && len(p.ProductIDs) == 0 {
+		return true
+	}
+	return slices.Contains(p.Categories, line.Category) || slices.Contains(p.ProductIDs, line.ProductID)
+}
+
+// ApplyPromotions sets the promotion discount of each line. Live promotions
+// run from highest to lowest priority and each line is discounted by at most
+// one promotion: once a promotion has used a line, lower-priority ones skip it.
+func ApplyPromotions(promotions []Promotion, lines []PricedLine, now time.Time) {
+	live := make([]Promotion, 0, len(promotions))
+	for _, p := range promotions {
+		if p.IsLive(now) {
+			live = append(live, p)
+		}
+	}
+	sort.SliceStable(live, func(i, j int) bool {
+		if live[i].Priority != live[j].Priority {
+			return live[i].Priority > live[j].Priority
+		}
+		return live[i].ID.Hex() < live[j].ID.Hex()
+	})
+
+	var subtotal Money
+	for i := range lines {
+		lines[i].Discount, lines[i].Promotion = Money{}, nil
+		subtotal = subtotal.Add(lines[i].Subtotal())
+	}
+
+	for _, p := range live {
+		if subtotal.Less(p.MinSubtotal) {
+			continue
+		}
+
+		var eligible []int
+		for i, line := range lines {
+			if line.Promotion == nil && p.appliesTo(line) {
+				eligible = append(eligible, i)
+			}
+		}
+
+		for i, discount := range p.discounts(lines, eligible) {
+			if !discount.IsPositive() {
+				continue
+			}
+			lines[i].Discount = discount.Min(lines[i].Subtotal())
+			lines[i].Promotion = &AppliedPromotion{ID: p.ID, Name: p.Name}
+		}
+	}
+}
+
+// discounts returns the discount promotion p gives each of the eligible lines,
+// keyed by line index.
+func (p *Promotion) discounts(lines []PricedLine, eligible []int) map[int]Money {
+	discounts := map[int]Money{}
+
+	switch p.Type {
+	case PromotionPercentOff:
+		for _, i := range eligible {
+			discounts[i] = lines[i].Subtotal().Percent(p.Percent)
+		}
+
+	case PromotionBuyXGetY:
+		// Lines are ranked from most to least expensive unit price so the
+		// free units are always the cheapest in each group
+		ranked := slices.Clone(eligible)
+		sort.SliceStable(ranked, func(a, b int) bool { return lines[ranked[b]].UnitPrice.Less(lines[ranked[a]].UnitPrice) })
+
+		units := 0
+		for _, i := range ranked {
+			units += lines[i].Quantity
+		}
+		free := units / (p.BuyQuantity + p.FreeQuantity) * p.FreeQuantity
+		for k := len(ranked) - 1; k >= 0 && free > 0; k-- {
+			i := ranked[k]
+			n := min(free, lines[i].Quantity)
+			discounts[i] = lines[i].UnitPrice.Mul(n)
+			free -= n
+		}
+
+	case PromotionFreeItem:
+		for _, i := range eligible {
+			if lines[i].ProductID == *p.FreeProductID {
+				discounts[i] = lines[i].UnitPrice
+				break
+			}
+		}
+	}
+
+	return discounts
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplyPromotions(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Hour)
+	freeProduct := primitive.NewObjectID()
+
+	tests := []struct {
+		name       string
+		promotions []Promotion
+		lines      []PricedLine
+		want       []int64
+	}{
+		{
+			name:       "buy 2 get 1 pools units across lines, cheapest free",
+			promotions: []Promotion{buyXGetY(2, 1)},
+			lines:      []PricedLine{pricedLine("a", 3000, 1), pricedLine("b", 1000, 1), pricedLine("c", 2000, 1)},
+			want:       []int64{0, 1000, 0},
+		},
+		{
+			name:       "free units spill over to the next cheapest line",
+			promotions: []Promotion{buyXGetY(1, 1)},
+			lines:      []PricedLine{pricedLine("a", 3000, 3), pricedLine("b", 1000, 1)},
+			want:       []int64{3000, 1000},
+		},
+		{
+			name:       "incomplete group gets nothing",
+			promotions: []Promotion{buyXGetY(2, 1)},
+			lines:      []PricedLine{pricedLine("a", 3000, 1), pricedLine("b", 1000, 1)},
+			want:       []int64{0, 0},
+		},
+		{
+			name: "higher priority claims its lines first",
+			promotions: []Promotion{
+				{ID: primitive.NewObjectID(), Type: PromotionPercentOff, Percent: 10, Priority: 1, Active: true},
+				{ID: primitive.NewObjectID(), Type: PromotionPercentOff, Percent: 50, Priority: 10, Categories: []string{"a"}, Active: true},
+			},
+			lines: []PricedLine{pricedLine("a", 1000, 1), pricedLine("b", 1000, 1)},
+			want:  []int64{500, 100},
+		},
+		{
+			name: "minimum subtotal not reached",
+			promotions: []Promotion{
+				{ID: primitive.NewObjectID(), Type: PromotionPercentOff, Percent: 10, MinSubtotal: NewMoney(5000), Active: true},
+			},
+			lines: []PricedLine{pricedLine("a", 1000, 2)},
+			want:  []int64{0},
+		},
+		{
+			name: "ended and inactive promotions are skipped",
+			promotions: []Promotion{
+				{ID: primitive.NewObjectID(), Type: PromotionPercentOff, Percent: 10, EndsAt: &ended, Active: true},
+				{ID: primitive.NewObjectID(), Type: PromotionPercentOff, Percent: 20},
+			},
+			lines: []PricedLine{pricedLine("a", 1000, 1)},
+			want:  []int64{0},
+		},
+		{
+			name: "free item takes one unit off",
+			promotions: []Promotion{
+				{ID: primitive.NewObjectID(), Type: PromotionFreeItem, FreeProductID: &freeProduct, Active: true},
+			},
+			lines: []PricedLine{pricedLine("a", 1000, 1), withProduct(pricedLine("b", 700, 3), freeProduct)},
+			want:  []int64{0, 700},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ApplyPromotions(tt.promotions, tt.lines, now)
+			for i, line := range tt.lines {
+				if line.Discount.Amount != tt.want[i] {
+					t.Errorf("line %d discount = %d, want %d", i, line.Discount.Amount, tt.want[i])
+				}
+				if (line.Promotion != nil) != (tt.want[i] > 0) {
+					t.Errorf("line %d promotion = %v, want one only when discounted", i, line.Promotion)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPromotionsLargeQuantities(t *testing.T) {
+	lines := []PricedLine{pricedLine("a", 100, 2_000_000_000)}
+	ApplyPromotions([]Promotion{buyXGetY(1, 1)}, lines, time.Now())
+
+	if want := int64(100 * 1_000_000_000); lines[0].Discount.Amount != want {
+		t.Errorf("discount = %d, want %d", lines[0].Discount.Amount, want)
+	}
+}
+
+func buyXGetY(buy, free int) Promotion {
+	return Promotion{ID: primitive.NewObjectID(), Type: PromotionBuyXGetY, BuyQuantity: buy, FreeQuantity: free, Active: true}
+}
+
+func withProduct(line PricedLine, productID primitive.ObjectID) PricedLine {
+	line.ProductID = productID
+	return line
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ShippingStandard = "standard"
+	ShippingExpress  = "express"
+	ShippingPickup   = "pickup"
+)
+
+const (
+	RateByWeight = "weight" // rate tiers are in grams of the whole order
+	RateByValue  = "value"  // rate tiers are in minor units of the discounted order value
+)
+
+var ErrShippingMethodUnavailable = errors.New("shipping method is not available for this address")
+
+// ShippingZone groups the destinations that share shipping methods: every
+// address in Countries or, when PostalPrefixes is set, only those whose postal
+// code starts with one of the prefixes. A prefix zone wins over a
+// country-wide one.
+type ShippingZone struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name           string             `json:"name" bson:"name"`
+	Countries      []string           `json:"countries" bson:"countries"` // ISO 3166-1 alpha-2
+	PostalPrefixes []string           `json:"postalPrefixes,omitempty" bson:"postalPrefixes,omitempty"`
+	Methods        []ShippingMethod   `json:"methods" bson:"methods"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ShippingMethod prices one way of delivering to a zone. The cost is that of
+// the rate tier with the highest Min not above the order's weight or value,
+// depending on RateBy; orders worth at least FreeOver ship free.
+type ShippingMethod struct {
+	Code     string         `json:"code" bson:"code"` // standard, express or pickup
+	Name     string         `json:"name" bson:"name"`
+	RateBy   string         `json:"rateBy" bson:"rateBy"`
+	Rates    []ShippingRate `json:"rates" bson:"rates"`
+	FreeOver *Money         `json:"freeOver,omitempty" bson:"freeOver,omitempty"`
+}
+
+type ShippingRate struct {
+	Min  int64 `json:"min" bson:"min"` // grams or minor units, depending on RateBy
+	Cost Money `json:"cost" bson:"cost"`
+}
+
+type ShippingZoneInput struct {
+	Name           string           `json:"name"`
+	Countries      []string         `json:"countries"`
+	PostalPrefixes []string         `json:"postalPrefixes"`
+	Methods        []ShippingMethod `json:"methods"`
+}
+
+// ShippingQuote is the price of one shipping method for a cart.
+type ShippingQuote struct {
+	Method string `json:"method"`
+	Name   string `json:"name"`
+	Cost   Money  `json:"cost"`
+	Free   bool   `json:"free"` // the free-shipping threshold was reached
+}
+
+// Normalize upper-cases the zone's countries and postal prefixes, sorts the
+// rate tiers of each method and validates the methods.
+func (z *ShippingZone) Normalize() error {
+	if z.Name == "" {
+		return errors.New("zone name is required")
+	}
+	if len(z.Countries) == 0 {
+		return errors.New("zone needs at least one country")
+	}
+	for i, country := range z.Countries {
+		z.Countries[i] = AddressCode(country)
+		if len(z.Countries[i]) != 2 {
+			return errors.New("countries must be two-letter ISO codes")
+		}
+	}
+	for i, prefix := range z.PostalPrefixes {
+		z.PostalPrefixes[i] = PostalCode(prefix)
+		if z.PostalPrefixes[i] == "" {
+			return errors.New("postal prefixes cannot be empty")
+		}
+	}
+
+	if len(z.Methods) == 0 {
+		return errors.New("zone needs at least one shipping method")
+	}
+	seen := map[string]bool{}
+	for i := range z.Methods {
+		m := &z.Methods[i]
+		switch m.Code {
+		case ShippingStandard, ShippingExpress, ShippingPickup:
+		default:
+			return errors.New("shipping method must be standard, express or pickup")
+		}
+		if seen[m.Code] {
+			return fmt.Errorf("duplicate shipping method %s", m.Code)
+		}
+		seen[m.Code] = true
+		if m.Name == "" {
+			m.Name = strings.ToUpper(m.Code[:1]) + m.Code[1:]
+		}
+
+		if m.RateBy != RateByWeight && m.RateBy != RateByValue {
+			return errors.New("rates must be by weight or value")
+		}
+		if len(m.Rates) == 0 {
+			return fmt.Errorf("shipping method %s needs at least one rate", m.Code)
+		}
+		for j := range m.Rates {
+			if m.Rates[j].Min < 0 {
+				return errors.New("rate minimums cannot be negative")
+			}
+			if err := m.Rates[j].Cost.Validate(); err != nil {
+				return err
+			}
+		}
+		sort.SliceStable(m.Rates, func(a, b int) bool { return m.Rates[a].Min < m.Rates[b].Min })
+		if m.Rates[0].Min != 0 {
+			return fmt.Errorf("shipping method %s needs a rate starting at 0", m.Code)
+		}
+
+		if m.FreeOver != nil {
+			if err := m.FreeOver.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// covers reports how well the zone matches an address: 0 for no match, 1 for
+// a country-wide match and 1 plus the prefix length for a postal-code match.
+func (z *ShippingZone) covers(address Address) int {
+	if !slices.Contains(z.Countries, AddressCode(address.Country)) {
+		return 0
+	}
+	if len(z.PostalPrefixes) == 0 {
+		return 1
+	}
+	postalCode := PostalCode(address.PostalCode)
+	best := 0
+	for _, prefix := range z.PostalPrefixes {
+		if strings.HasPrefix(postalCode, prefix) {
+			best = max(best, 1+len(prefix))
+		}
+	}
+	return best
+}
+
+// MatchShippingZone picks the zone for an address: the one with the longest
+// matching postal prefix, else a country-wide one. It returns nil when no zone
+// covers the address.
+func MatchShippingZone(zones []ShippingZone, address Address) *ShippingZone {
+	var match *ShippingZone
+	best := 0
+	for i := range zones {
+		if score := zones[i].covers(address); score > best {
+			match, best = &zones[i], score
+		}
+	}
+	return match
+}
+
+// Method returns the zone's method with code, or nil.
+func (z *ShippingZone) Method(code string) *ShippingMethod {
+	for i := range z.Methods {
+		if z.Methods[i].Code == code {
+			return &z.Methods[i]
+		}
+	}
+	return nil
+}
+
+// Quote prices the method for lines whose discounted value is value.
+func (m *ShippingMethod) Quote(lines []PricedLine, value Money) ShippingQuote {
+	quote := ShippingQuote{Method: m.Code, Name: m.Name, Cost: NewMoney(0)}
+	if m.FreeOver != nil && !value.Less(*m.FreeOver) {
+		quote.Free = true
+		return quote
+	}
+
+	measure := value.Amount
+	if m.RateBy == RateByWeight {
+		measure = 0
+		for _, line := range lines {
+			measure += int64(line.Weight) * int64(line.Quantity)
+		}
+	}
+	for _, rate := range m.Rates {
+		if rate.Min <= measure {
+			quote.Cost = rate.Cost
+		}
+	}
+	return quote
+}
+
+// Quotes prices every method of the zone.
+func (z *ShippingZone) Quotes(lines []PricedLine, value Money) []ShippingQuote {
+	quotes := make([]ShippingQuote, len(z.Methods))
+	for i := range z.Methods {
+		quotes[i] = z.Methods[i].Quote(lines, value)
+	}
+	return quotes
+}
@@ -0,0 +1,83 @@
+package models
+
+import "testing"
+
+func TestMatchShippingZone(t *testing.T) {
+	zones := []ShippingZone{
+		{Name: "London", Countries: []string{"GB"}, PostalPrefixes: []string{"E", "EC"}},
+		{Name: "City of London", Countries: []string{"GB"}, PostalPrefixes: []string{"EC1"}},
+		{Name: "UK", Countries: []string{"GB"}},
+		{Name: "Benelux", Countries: []string{"BE", "NL", "LU"}},
+	}
+
+	tests := []struct {
+		name    string
+		address Address
+		want    string
+	}{
+		{name: "longest postal prefix wins", address: Address{Country: "GB", PostalCode: "EC1A 1BB"}, want: "City of London"},
+		{name: "longest prefix within a zone", address: Address{Country: "GB", PostalCode: "EC2A 2AA"}, want: "London"},
+		{name: "postal code is normalized", address: Address{Country: "gb", PostalCode: " e1 6an"}, want: "London"},
+		{name: "no prefix matches, country-wide zone", address: Address{Country: "GB", PostalCode: "M1 1AE"}, want: "UK"},
+		{name: "one of several countries", address: Address{Country: "NL", PostalCode: "1012"}, want: "Benelux"},
+		{name: "no zone for the country", address: Address{Country: "US", PostalCode: "E1"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if zone := MatchShippingZone(zones, tt.address); zone != nil {
+				got = zone.Name
+			}
+			if got != tt.want {
+				t.Errorf("MatchShippingZone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShippingMethodQuote(t *testing.T) {
+	freeOver := NewMoney(10000)
+	byValue := ShippingMethod{
+		Code:     ShippingStandard,
+		RateBy:   RateByValue,
+		Rates:    []ShippingRate{{Min: 0, Cost: NewMoney(800)}, {Min: 5000, Cost: NewMoney(400)}},
+		FreeOver: &freeOver,
+	}
+	byWeight := ShippingMethod{
+		Code:   ShippingExpress,
+		RateBy: RateByWeight,
+		Rates:  []ShippingRate{{Min: 0, Cost: NewMoney(1000)}, {Min: 2000, Cost: NewMoney(1500)}},
+	}
+	heavy := pricedLine("a", 1000, 3)
+	heavy.Weight = 700
+
+	tests := []struct {
+		name     string
+		method   ShippingMethod
+		lines    []PricedLine
+		value    int64
+		want     int64
+		wantFree bool
+	}{
+		{name: "lowest tier", method: byValue, value: 4999, want: 800},
+		{name: "tier minimum is inclusive", method: byValue, value: 5000, want: 400},
+		{name: "just below the free threshold", method: byValue, value: 9999, want: 400},
+		{name: "free at the threshold", method: byValue, value: 10000, want: 0, wantFree: true},
+		{name: "free above the threshold", method: byValue, value: 25000, want: 0, wantFree: true},
+		{name: "weight of every unit counts", method: byWeight, lines: []PricedLine{heavy}, value: 3000, want: 1500},
+		{name: "light order by weight", method: byWeight, lines: []PricedLine{pricedLine("a", 1000, 3)}, value: 3000, want: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := tt.method.Quote(tt.lines, NewMoney(tt.value))
+			if quote.Cost.Amount != tt.want || quote.Free != tt.wantFree {
+				t.Errorf("Quote() = %d (free %t), want %d (free %t)", quote.Cost.Amount, quote.Free, tt.want, tt.wantFree)
+			}
+			if quote.Method != tt.method.Code {
+				t.Errorf("Quote() method = %q, want %q", quote.Method, tt.method.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxRule is the tax charged on orders shipped to Country or, when Region is
+// set, to one region of it. Rate is a percentage of each line after its
+// discounts; CategoryRates overrides it for categories taxed differently, such
+// as children's clothing. Inclusive rules treat prices as already containing
+// the tax, so it is reported per line but not added to the total.
+type TaxRule struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name          string             `json:"name" bson:"name"`
+	Country       string             `json:"country" bson:"country"`         // ISO 3166-1 alpha-2
+	Region        string             `json:"region,omitempty" bson:"region"` // empty for the whole country
+	Rate          float64            `json:"rate" bson:"rate"`
+	CategoryRates map[string]float64 `json:"categoryRates,omitempty" bson:"categoryRates,omitempty"`
+	Inclusive     bool               `json:"inclusive" bson:"inclusive"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type TaxRuleInput struct {
+	Name          string             `json:"name"`
+	Country       string             `json:"country"`
+	Region        string             `json:"region"`
+	Rate          float64            `json:"rate"`
+	CategoryRates map[string]float64 `json:"categoryRates"`
+	Inclusive     bool               `json:"inclusive"`
+}
+
+// Normalize upper-cases the country and region codes and validates the rates.
+func (t *TaxRule) Normalize() error {
+	t.Country = AddressCode(t.Country)
+	t.Region = AddressCode(t.Region)
+
+	if len(t.Country) != 2 {
+		return errors.New("country must be a two-letter ISO code")
+	}
+	if t.Rate < 0 || t.Rate > 100 {
+		return errors.New("rate must be between 0 and 100")
+	}
+	for category, rate := range t.CategoryRates {
+		if category == "" {
+			return errors.New("category rates need a category")
+		}
+		if rate < 0 || rate > 100 {
+			return errors.New("category rates must be between 0 and 100")
+		}
+	}
+	return nil
+}
+
+// RateFor returns the rate charged on lines of category.
+func (t *TaxRule) RateFor(category string) float64 {
+	if rate, ok := t.CategoryRates[category]; ok {
+		return rate
+	}
+	return t.Rate
+}
+
+// MatchTaxRule picks the rule for an address from the rules of its country: a
+// rule for its region wins over the country-wide one. It returns nil when no
+// rule applies.
+func MatchTaxRule(rules []TaxRule, address Address) *TaxRule {
+	country, region := AddressCode(address.Country), AddressCode(address.Region)
+
+	var match *TaxRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Country != country {
+			continue
+		}
+		if rule.Region == "" && match == nil {
+			match = rule
+		} else if rule.Region != "" && rule.Region == region {
+			return rule
+		}
+	}
+	return match
+}
+
+// ApplyTax sets the tax of each line under rule and returns the total. The
+// taxable amount of a line is its net price less its share of the coupon
+// discount, given by couponShares (nil when no coupon applied). A nil rule
+// clears the tax.
+func ApplyTax(rule *TaxRule, lines []PricedLine, couponShares []Money) Money {
+	total := NewMoney(0)
+	for i := range lines {
+		lines[i].Tax, lines[i].TaxRate = Money{}, 0
+		if rule == nil {
+			continue
+		}
+
+		base := lines[i].Net()
+		if couponShares != nil {
+			base = base.Sub(couponShares[i])
+		}
+		rate := rule.RateFor(lines[i].Category)
+		if rule.Inclusive {
+			// The price already holds the tax: extract rate/(100+rate) of it
+			lines[i].Tax = base.Percent(rate * 100 / (100 + rate))
+		} else {
+			lines[i].Tax = base.Percent(rate)
+		}
+		lines[i].TaxRate = rate
+		total = total.Add(lines[i].Tax)
+	}
+	return total
+}
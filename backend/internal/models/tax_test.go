@@ -0,0 +1,98 @@
+package models
+
+import "testing"
+
+func TestMatchTaxRule(t *testing.T) {
+	rules := []TaxRule{
+		{Name: "US", Country: "US"},
+		{Name: "California", Country: "US", Region: "CA"},
+		{Name: "Germany", Country: "DE"},
+	}
+
+	tests := []struct {
+		name    string
+		address Address
+		want    string
+	}{
+		{name: "region rule wins over country rule", address: Address{Country: "US", Region: "CA"}, want: "California"},
+		{name: "codes are matched case-insensitively", address: Address{Country: "us", Region: "ca"}, want: "California"},
+		{name: "other region falls back to the country", address: Address{Country: "US", Region: "NY"}, want: "US"},
+		{name: "no region", address: Address{Country: "DE"}, want: "Germany"},
+		{name: "no rule for the country", address: Address{Country: "FR"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if rule := MatchTaxRule(rules, tt.address); rule != nil {
+				got = rule.Name
+			}
+			if got != tt.want {
+				t.Errorf("MatchTaxRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTax(t *testing.T) {
+	tests := []struct {
+		name         string
+		rule         *TaxRule
+		lines        []PricedLine
+		couponShares []int64
+		want         []int64
+	}{
+		{
+			name:  "exclusive rate on the net price",
+			rule:  &TaxRule{Rate: 10},
+			lines: []PricedLine{pricedLine("a", 1000, 2)},
+			want:  []int64{200},
+		},
+		{
+			name:  "inclusive rate is extracted from the price",
+			rule:  &TaxRule{Rate: 20, Inclusive: true},
+			lines: []PricedLine{pricedLine("a", 1200, 1)},
+			want:  []int64{200},
+		},
+		{
+			name:  "category rate overrides the rule rate",
+			rule:  &TaxRule{Rate: 20, CategoryRates: map[string]float64{"kids": 5}},
+			lines: []PricedLine{pricedLine("kids", 1000, 1), pricedLine("a", 1000, 1)},
+			want:  []int64{50, 200},
+		},
+		{
+			name:         "coupon share is taken off before tax",
+			rule:         &TaxRule{Rate: 10},
+			lines:        []PricedLine{pricedLine("a", 1000, 1), pricedLine("b", 1000, 1)},
+			couponShares: []int64{500, 0},
+			want:         []int64{50, 100},
+		},
+		{
+			name:  "no rule clears the tax",
+			lines: []PricedLine{{UnitPrice: NewMoney(1000), Quantity: 1, Tax: NewMoney(99), TaxRate: 9}},
+			want:  []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shares []Money
+			for _, share := range tt.couponShares {
+				shares = append(shares, NewMoney(share))
+			}
+
+			total := ApplyTax(tt.rule, tt.lines, shares)
+
+			var want int64
+			for i, line := range tt.lines {
+				if line.Tax.Amount != tt.want[i] {
+					t.Errorf("line %d tax = %d, want %d", i, line.Tax.Amount, tt.want[i])
+				}
+				want += tt.want[i]
+			}
+			if total.Amount != want {
+				t.Errorf("total = %d, want %d", total.Amount, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const FakeProviderName = "fake"
+
+// FakeSignatureHeader carries the HMAC of a fake webhook's body.
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider is an in-process gateway for development and tests. Every
+// payment is authorized straight away, and captures and refunds succeed as
+// long as they stay within the authorized and captured amounts. Its state
+// lives in memory and is lost on restart. Webhooks are signed with
+// webhookSecret; SignEvent produces test events the webhook endpoint accepts.
+type FakeProvider struct {
+	mu            sync.Mutex
+	payments      map[string]*fakePayment
+	byOrder       map[primitive.ObjectID]string
+	webhookSecret string
+}
+
+type fakePayment struct {
+	authorized, captured, refunded models.Money
+}
+
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		payments:      make(map[string]*fakePayment),
+		byOrder:       make(map[primitive.ObjectID]string),
+		webhookSecret: webhookSecret,
+	}
+}
+
+func (f *FakeProvider) Name() string { return FakeProviderName }
+
+func (f *FakeProvider) Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ref, ok := f.byOrder[req.OrderID]
+	if !ok {
+		ref = "fake_" + primitive.NewObjectID().Hex()
+		f.payments[ref] = &fakePayment{authorized: req.Amount}
+		f.byOrder[req.OrderID] = ref
+	}
+
+	return &Authorization{Ref: ref, ClientSecret: ref + "_secret", Status: models.PaymentAuthorized}, nil
+}
+
+func (f *FakeProvider) Capture(ctx context.Context, ref string, amount models.Money) (models.Money, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.payments[ref]
+	if !ok {
+		return models.Money{}, ErrUnknownPayment
+	}
+	if p.authorized.Sub(p.captured).Less(amount) {
+		return models.Money{}, fmt.Errorf("%w: capture exceeds the authorized amount", ErrDeclined)
+	}
+
+	p.captured = p.captured.Add(amount)
+	return amount, nil
+}
+
+func (f *FakeProvider) Void(ctx context.Context, ref string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.payments[ref]
+	if !ok {
+		return ErrUnknownPayment
+	}
+	if p.captured.IsPositive() {
+		return fmt.Errorf("%w: captured payments are refunded, not voided", ErrDeclined)
+	}
+
+	p.authorized = p.captured
+	return nil
+}
+
+func (f *FakeProvider) Refund(ctx context.Context, ref string, amount models.Money) (models.Money, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.payments[ref]
+	if !ok {
+		return models.Money{}, ErrUnknownPayment
+	}
+	if p.captured.Sub(p.refunded).Less(amount) {
+		return models.Money{}, fmt.Errorf("%w: refund exceeds the captured amount", ErrDeclined)
+	}
+
+	p.refunded = p.refunded.Add(amount)
+	return amount, nil
+}
+
+// ParseWebhook checks the body's HMAC in FakeSignatureHeader and decodes the
+// event sent as JSON.
+func (f *FakeProvider) ParseWebhook(payload []byte, header http.Header) (*models.PaymentEvent, error) {
+	if !VerifySignature(f.webhookSecret, payload, header.Get(FakeSignatureHeader)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event models.PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// SignEvent encodes event as the fake provider would send it, giving it an ID
+// when it has none, and returns the body with its signed headers.
+func (f *FakeProvider) SignEvent(event models.PaymentEvent) ([]byte, http.Header, error) {
+	if event.ID == "" {
+		event.ID = "evt_" + primitive.NewObjectID().Hex()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(FakeSignatureHeader, Sign(f.webhookSecret, payload))
+	return payload, header, nil
+}
@@ -0,0 +1,75 @@
+// Package payments defines the interface payment gateways are plugged in
+// through and ships a fake gateway for local development.
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrDeclined means the provider refused the operation, for example
+	// because the card was declined or the amount exceeds what is held.
+	ErrDeclined = errors.New("payment declined")
+
+	ErrUnknownPayment = errors.New("unknown payment")
+
+	// ErrInvalidSignature means a webhook was not signed with the configured
+	// secret.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Provider is a payment gateway. Payments are named by the reference the
+// provider returned from Authorize, and amounts are in the store's currency.
+type Provider interface {
+	Name() string
+
+	// Authorize opens a payment for an order. The status is
+	// models.PaymentAuthorized when the funds are already held, or
+	// models.PaymentPending when the customer still has to confirm with the
+	// client secret. Authorizing the same order twice returns the same payment.
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error)
+
+	// Capture collects amount of an authorized payment and returns the amount
+	// the provider reports as captured.
+	Capture(ctx context.Context, ref string, amount models.Money) (models.Money, error)
+
+	// Void releases the funds held by an authorization that will not be
+	// captured, such as that of a cancelled order.
+	Void(ctx context.Context, ref string) error
+
+	// Refund returns amount of a captured payment and returns the amount the
+	// provider reports as refunded.
+	Refund(ctx context.Context, ref string, amount models.Money) (models.Money, error)
+
+	// ParseWebhook verifies the signature of a notification the provider
+	// sent and decodes it. It returns ErrInvalidSignature when the signature
+	// does not match.
+	ParseWebhook(payload []byte, header http.Header) (*models.PaymentEvent, error)
+}
+
+type AuthorizeRequest struct {
+	OrderID primitive.ObjectID
+	Amount  models.Money
+}
+
+type Authorization struct {
+	Ref          string
+	ClientSecret string
+	Status       string
+}
+
+// Registry holds the configured providers by name.
+type Registry map[string]Provider
+
+func NewRegistry(providers ...Provider) Registry {
+	registry := Registry{}
+	for _, p := range providers {
+		registry[p.Name()] = p
+	}
+	return registry
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// numberToFloat converts the numeric types an aggregation can produce into a
+// float64. The driver decodes $sum results as int32, int64, double or
+// decimal128 depending on the inputs, so results must not be type-asserted.
+func numberToFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case primitive.Decimal128:
+		value, err := strconv.ParseFloat(n.String(), 64)
+		return value, err == nil
+	}
+	return 0, false
+}
+
+// numberToMoney converts an aggregated sum of minor units into Money.
+func numberToMoney(v interface{}) (models.Money, bool) {
+	amount, ok := numberToFloat(v)
+	return models.NewMoney(int64(math.Round(amount))), ok
+}
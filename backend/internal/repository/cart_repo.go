@@ -4,26 +4,23 @@ import (
 	"context"
 	"time"
 
-	"github.com/serikkalibeknur/project-clothesstore/config"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type CartRepository struct {
-	client *mongo.Client
+type MongoCartRepository struct {
+	collection *mongo.Collection
 }
 
-func NewCartRepository(client *mongo.Client) *CartRepository {
-	return &CartRepository{client: client}
+func NewMongoCartRepository(db *mongo.Database) *MongoCartRepository {
+	return &MongoCartRepository{collection: db.Collection("carts")}
 }
 
-func (cr *CartRepository) GetCart(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error) {
-	collection := config.GetCollection(cr.client, "carts")
-
+func (cr *MongoCartRepository) GetCart(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error) {
 	var cart models.Cart
-	err := collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&cart)
+	err := cr.collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&cart)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// Create new cart if it doesn't exist
@@ -36,15 +33,25 @@ func (cr *CartRepository) GetCart(ctx context.Context, userID primitive.ObjectID
 		return nil, err
 	}
 
+	// Carts saved before lines had IDs get them on first read
+	if assignLineIDs(cart.Products) {
+		_, err = cr.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": cart.ID},
+			bson.M{"$set": bson.M{"products": cart.Products}},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &cart, nil
 }
 
-func (cr *CartRepository) AddToCart(ctx context.Context, userID, productID primitive.ObjectID, quantity int, size string) error {
-	collection := config.GetCollection(cr.client, "carts")
-
+func (cr *MongoCartRepository) AddToCart(ctx context.Context, userID primitive.ObjectID, item models.CartItem) error {
 	// Check if item already exists
 	var cart models.Cart
-	err := collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&cart)
+	err := cr.collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&cart)
 
 	if err != nil && err != mongo.ErrNoDocuments {
 		return err
@@ -53,47 +60,20 @@ func (cr *CartRepository) AddToCart(ctx context.Context, userID, productID primi
 	if err == mongo.ErrNoDocuments {
 		// Create new cart
 		newCart := models.Cart{
-			ID:     primitive.NewObjectID(),
-			UserID: userID,
-			Products: []models.CartItem{
-				{
-					ProductID: productID,
-					Quantity:  quantity,
-					Size:      size,
-					AddedAt:   time.Now(),
-				},
-			},
+			ID:        primitive.NewObjectID(),
+			UserID:    userID,
+			Products:  mergeCartItem(nil, item),
 			UpdatedAt: time.Now(),
 		}
-		_, err := collection.InsertOne(ctx, newCart)
+		_, err := cr.collection.InsertOne(ctx, newCart)
 		return err
 	}
 
-	// Check if product already exists in cart
-	existingIndex := -1
-	for i, item := range cart.Products {
-		if item.ProductID == productID && item.Size == size {
-			existingIndex = i
-			break
-		}
-	}
-
-	if existingIndex != -1 {
-		// Update quantity
-		cart.Products[existingIndex].Quantity += quantity
-	} else {
-		// Add new item
-		cart.Products = append(cart.Products, models.CartItem{
-			ProductID: productID,
-			Quantity:  quantity,
-			Size:      size,
-			AddedAt:   time.Now(),
-		})
-	}
+	cart.Products = mergeCartItem(cart.Products, item)
 
 	cart.UpdatedAt = time.Now()
 
-	_, err = collection.UpdateOne(
+	_, err = cr.collection.UpdateOne(
 		ctx,
 		bson.M{"userID": userID},
 		bson.M{"$set": bson.M{"products": cart.Products, "updatedAt": cart.UpdatedAt}},
@@ -102,64 +82,132 @@ func (cr *CartRepository) AddToCart(ctx context.Context, userID, productID primi
 	return err
 }
 
-func (cr *CartRepository) RemoveFromCart(ctx context.Context, userID, productID primitive.ObjectID) error {
-	collection := config.GetCollection(cr.client, "carts")
+// mergeCartItem adds item to products, increasing the quantity of an existing
+// line for the same product variant instead of duplicating it.
+func mergeCartItem(products []models.CartItem, item models.CartItem) []models.CartItem {
+	for i, existing := range products {
+		if existing.SameVariant(item) {
+			products[i].Quantity += item.Quantity
+			return products
+		}
+	}
 
-	_, err := collection.UpdateOne(
-		ctx,
-		bson.M{"userID": userID},
-		bson.M{
-			"$pull": bson.M{"products": bson.M{"productID": productID}},
-			"$set":  bson.M{"updatedAt": time.Now()},
-		},
-	)
+	if item.LineID.IsZero() {
+		item.LineID = primitive.NewObjectID()
+	}
+	return append(products, item)
+}
 
-	return err
+// replaceCartLine swaps in line for the entry with the same LineID, folding it
+// into another line that already holds the same variant.
+func replaceCartLine(products []models.CartItem, line models.CartItem) ([]models.CartItem, bool) {
+	index := -1
+	for i, existing := range products {
+		if existing.LineID == line.LineID {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return products, false
+	}
+
+	for i, existing := range products {
+		if i != index && existing.SameVariant(line) {
+			products[i].Quantity += line.Quantity
+			return append(products[:index], products[index+1:]...), true
+		}
+	}
+
+	products[index] = line
+	return products, true
 }
 
-func (cr *CartRepository) UpdateCartItemQuantity(ctx context.Context, userID, productID primitive.ObjectID, quantity int) error {
-	collection := config.GetCollection(cr.client, "carts")
+// assignLineIDs gives every line without an ID a new one and reports whether
+// any line changed.
+func assignLineIDs(products []models.CartItem) bool {
+	changed := false
+	for i := range products {
+		if products[i].LineID.IsZero() {
+			products[i].LineID = primitive.NewObjectID()
+			changed = true
+		}
+	}
+	return changed
+}
 
+// UpdateCartLine replaces the line with the same LineID as line. When the new
+// size or color turns it into a duplicate of another line, the two are merged.
+func (cr *MongoCartRepository) UpdateCartLine(ctx context.Context, userID primitive.ObjectID, line models.CartItem) error {
 	var cart models.Cart
-	err := collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&cart)
+	err := cr.collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&cart)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrCartLineNotFound
+		}
 		return err
 	}
 
-	// Find and update item quantity
-	for i, item := range cart.Products {
-		if item.ProductID == productID {
-			cart.Products[i].Quantity = quantity
-			break
-		}
+	products, ok := replaceCartLine(cart.Products, line)
+	if !ok {
+		return ErrCartLineNotFound
 	}
 
-	cart.UpdatedAt = time.Now()
-
-	_, err = collection.UpdateOne(
+	_, err = cr.collection.UpdateOne(
 		ctx,
 		bson.M{"userID": userID},
-		bson.M{"$set": bson.M{"products": cart.Products, "updatedAt": cart.UpdatedAt}},
+		bson.M{"$set": bson.M{"products": products, "updatedAt": time.Now()}},
 	)
 
 	return err
 }
 
-func (cr *CartRepository) ClearCart(ctx context.Context, userID primitive.ObjectID) error {
-	collection := config.GetCollection(cr.client, "carts")
+func (cr *MongoCartRepository) RemoveCartLine(ctx context.Context, userID, lineID primitive.ObjectID) error {
+	result, err := cr.collection.UpdateOne(
+		ctx,
+		bson.M{"userID": userID, "products.lineID": lineID},
+		bson.M{
+			"$pull": bson.M{"products": bson.M{"lineID": lineID}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrCartLineNotFound
+	}
+
+	return nil
+}
 
-	_, err := collection.UpdateOne(
+func (cr *MongoCartRepository) ClearCart(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := cr.collection.UpdateOne(
 		ctx,
 		bson.M{"userID": userID},
-		bson.M{"$set": bson.M{"products": []models.CartItem{}, "updatedAt": time.Now()}},
+		bson.M{
+			"$set":   bson.M{"products": []models.CartItem{}, "updatedAt": time.Now()},
+			"$unset": bson.M{"couponCode": ""},
+		},
 	)
 
 	return err
 }
 
-func (cr *CartRepository) DeleteCart(ctx context.Context, userID primitive.ObjectID) error {
-	collection := config.GetCollection(cr.client, "carts")
+// SetCartCoupon stores the coupon code applied to the cart, or removes it when
+// code is empty.
+func (cr *MongoCartRepository) SetCartCoupon(ctx context.Context, userID primitive.ObjectID, code string) error {
+	update := bson.M{"$set": bson.M{"couponCode": code, "updatedAt": time.Now()}}
+	if code == "" {
+		update = bson.M{"$unset": bson.M{"couponCode": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+
+	_, err := cr.collection.UpdateOne(ctx, bson.M{"userID": userID}, update)
+	return err
+}
 
-	_, err := collection.DeleteOne(ctx, bson.M{"userID": userID})
+func (cr *MongoCartRepository) DeleteCart(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := cr.collection.DeleteOne(ctx, bson.M{"userID": userID})
 	return err
 }
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCouponRepository keeps each user's uses of a coupon in a collection of
+// its own, so a coupon document does not grow with every customer.
+type MongoCouponRepository struct {
+	collection  *mongo.Collection
+	redemptions *mongo.Collection
+}
+
+func NewMongoCouponRepository(db *mongo.Database) *MongoCouponRepository {
+	return &MongoCouponRepository{
+		collection:  db.Collection("coupons"),
+		redemptions: db.Collection("coupon_redemptions"),
+	}
+}
+
+func (cr *MongoCouponRepository) CreateCoupon(ctx context.Context, coupon *models.Coupon) (*models.Coupon, error) {
+	result, err := cr.collection.InsertOne(ctx, coupon)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateCouponCode
+		}
+		return nil, err
+	}
+
+	coupon.ID = result.InsertedID.(primitive.ObjectID)
+	return coupon, nil
+}
+
+func (cr *MongoCouponRepository) GetCouponByID(ctx context.Context, couponID primitive.ObjectID) (*models.Coupon, error) {
+	return cr.findOne(ctx, bson.M{"_id": couponID})
+}
+
+func (cr *MongoCouponRepository) GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	return cr.findOne(ctx, bson.M{"code": models.NormalizeCouponCode(code)})
+}
+
+func (cr *MongoCouponRepository) findOne(ctx context.Context, filter bson.M) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := cr.collection.FindOne(ctx, filter).Decode(&coupon)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (cr *MongoCouponRepository) GetAllCoupons(ctx context.Context) ([]models.Coupon, error) {
+	cursor, err := cr.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var coupons []models.Coupon
+	if err = cursor.All(ctx, &coupons); err != nil {
+		return nil, err
+	}
+
+	return coupons, nil
+}
+
+// UpdateCoupon replaces the coupon's settings. Usage counters are left alone.
+func (cr *MongoCouponRepository) UpdateCoupon(ctx context.Context, couponID primitive.ObjectID, coupon *models.Coupon) (*models.Coupon, error) {
+	updateData := bson.M{
+		"code":          coupon.Code,
+		"type":          coupon.Type,
+		"percent":       coupon.Percent,
+		"amount":        coupon.Amount,
+		"minOrderValue": coupon.MinOrderValue,
+		"expiresAt":     coupon.ExpiresAt,
+		"usageLimit":    coupon.UsageLimit,
+		"perUserLimit":  coupon.PerUserLimit,
+		"categories":    coupon.Categories,
+		"productIDs":    coupon.ProductIDs,
+		"active":        coupon.Active,
+		"updatedAt":     coupon.UpdatedAt,
+	}
+
+	result := cr.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": couponID},
+		bson.M{"$set": updateData},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if err := result.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateCouponCode
+		}
+		return nil, err
+	}
+
+	var updatedCoupon models.Coupon
+	if err := result.Decode(&updatedCoupon); err != nil {
+		return nil, err
+	}
+
+	return &updatedCoupon, nil
+}
+
+func (cr *MongoCouponRepository) DeleteCoupon(ctx context.Context, couponID primitive.ObjectID) error {
+	if _, err := cr.collection.DeleteOne(ctx, bson.M{"_id": couponID}); err != nil {
+		return err
+	}
+	_, err := cr.redemptions.DeleteMany(ctx, bson.M{"couponID": couponID})
+	return err
+}
+
+// GetUserRedemptions returns how many times userID has used the coupon.
+func (cr *MongoCouponRepository) GetUserRedemptions(ctx context.Context, couponID, userID primitive.ObjectID) (int, error) {
+	var redemption models.CouponRedemption
+	err := cr.redemptions.FindOne(ctx, bson.M{"couponID": couponID, "userID": userID}).Decode(&redemption)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return redemption.Count, nil
+}
+
+// RedeemCoupon records one use of the coupon by userID. Each update only
+// matches while its limit has room; when the user's uses are already at the
+// limit, the upsert collides with their redemption on the unique index. So
+// concurrent checkouts cannot overspend a coupon. It must run in a
+// transaction, which takes the user's use back when the overall limit is
+// reached.
+func (cr *MongoCouponRepository) RedeemCoupon(ctx context.Context, coupon *models.Coupon, userID primitive.ObjectID) error {
+	now := time.Now()
+
+	filter := bson.M{"couponID": coupon.ID, "userID": userID}
+	if coupon.PerUserLimit > 0 {
+		filter["count"] = bson.M{"$lt": coupon.PerUserLimit}
+	}
+	_, err := cr.redemptions.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$inc": bson.M{"count": 1},
+			"$set": bson.M{"updatedAt": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCouponUnavailable
+		}
+		return err
+	}
+
+	filter = bson.M{"_id": coupon.ID, "active": true}
+	if coupon.UsageLimit > 0 {
+		filter["usedCount"] = bson.M{"$lt": coupon.UsageLimit}
+	}
+	result, err := cr.collection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$inc": bson.M{"usedCount": 1},
+			"$set": bson.M{"updatedAt": now},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCouponUnavailable
+	}
+	return nil
+}
+
+// ReleaseCoupon gives back one use of the coupon by userID, for cancelled
+// orders. Like RedeemCoupon, it must run in a transaction.
+func (cr *MongoCouponRepository) ReleaseCoupon(ctx context.Context, couponID, userID primitive.ObjectID) error {
+	now := time.Now()
+	result, err := cr.redemptions.UpdateOne(
+		ctx,
+		bson.M{"couponID": couponID, "userID": userID, "count": bson.M{"$gt": 0}},
+		bson.M{
+			"$inc": bson.M{"count": -1},
+			"$set": bson.M{"updatedAt": now},
+		},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return err
+	}
+
+	_, err = cr.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": couponID, "usedCount": bson.M{"$gt": 0}},
+		bson.M{
+			"$inc": bson.M{"usedCount": -1},
+			"$set": bson.M{"updatedAt": now},
+		},
+	)
+	return err
+}
+
+// EnsureIndexes makes coupon codes unique and allows one redemption document
+// per coupon and user.
+func (cr *MongoCouponRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := cr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.redemptions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "couponID", Value: 1}, {Key: "userID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,28 @@
+package repository
+
+import "errors"
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrCartLineNotFound  = errors.New("cart line not found")
+
+	// ErrOrderStatusChanged means the order left the expected status before a
+	// transition could be applied.
+	ErrOrderStatusChanged = errors.New("order status changed")
+
+	ErrDuplicateCouponCode = errors.New("coupon code already exists")
+
+	// ErrCouponUnavailable means a coupon could not be redeemed because it was
+	// deactivated or a usage limit was reached.
+	ErrCouponUnavailable = errors.New("coupon unavailable")
+
+	ErrDuplicateTaxRule = errors.New("tax rule for this destination already exists")
+
+	// ErrPaymentStatusChanged means the payment left the expected status before
+	// a change could be applied.
+	ErrPaymentStatusChanged = errors.New("payment status changed")
+
+	ErrDuplicateWebhookEvent = errors.New("webhook event already received")
+
+	ErrDuplicateIdempotencyKey = errors.New("idempotency key already used")
+)
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoIdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoIdempotencyRepository(db *mongo.Database) *MongoIdempotencyRepository {
+	return &MongoIdempotencyRepository{collection: db.Collection("idempotency_keys")}
+}
+
+// CreateIdempotencyRecord reserves a key for a user. It returns
+// ErrDuplicateIdempotencyKey when the user already holds the key; a record
+// that expired before now is replaced without waiting for the TTL index.
+func (ir *MongoIdempotencyRepository) CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord, now time.Time) (*models.IdempotencyRecord, error) {
+	_, err := ir.collection.DeleteOne(ctx, bson.M{"userID": record.UserID, "key": record.Key, "expiresAt": bson.M{"$lte": now}})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ir.collection.InsertOne(ctx, record)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateIdempotencyKey
+		}
+		return nil, err
+	}
+
+	record.ID = result.InsertedID.(primitive.ObjectID)
+	return record, nil
+}
+
+// GetIdempotencyRecord returns the user's record for key unless it expired
+// before now.
+func (ir *MongoIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, userID, key string, now time.Time) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	err := ir.collection.FindOne(ctx, bson.M{"userID": userID, "key": key, "expiresAt": bson.M{"$gt": now}}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// CompleteIdempotencyRecord stores the response to the request that reserved
+// the key.
+func (ir *MongoIdempotencyRepository) CompleteIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	_, err := ir.collection.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{"$set": bson.M{
+		"status":      record.Status,
+		"statusCode":  record.StatusCode,
+		"contentType": record.ContentType,
+		"body":        record.Body,
+	}})
+	return err
+}
+
+func (ir *MongoIdempotencyRepository) DeleteIdempotencyRecord(ctx context.Context, recordID primitive.ObjectID) error {
+	_, err := ir.collection.DeleteOne(ctx, bson.M{"_id": recordID})
+	return err
+}
+
+// EnsureIndexes makes keys unique per user and lets MongoDB drop records once
+// they expire.
+func (ir *MongoIdempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := ir.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
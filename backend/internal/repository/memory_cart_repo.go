@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryCartRepository keys carts by user ID, mirroring the one-cart-per-user
+// lookups of the MongoDB implementation.
+type MemoryCartRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryCartRepository(store *MemoryStore) *MemoryCartRepository {
+	return &MemoryCartRepository{store: store}
+}
+
+func (cr *MemoryCartRepository) GetCart(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error) {
+	cr.store.mu.RLock()
+	defer cr.store.mu.RUnlock()
+
+	cart, ok := cr.store.carts[userID]
+	if !ok {
+		return &models.Cart{
+			UserID:    userID,
+			Products:  []models.CartItem{},
+			UpdatedAt: time.Now(),
+		}, nil
+	}
+
+	cart = cloneCart(cart)
+	return &cart, nil
+}
+
+func (cr *MemoryCartRepository) AddToCart(ctx context.Context, userID primitive.ObjectID, item models.CartItem) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	cart, ok := cr.store.carts[userID]
+	if !ok {
+		cart = models.Cart{
+			ID:     primitive.NewObjectID(),
+			UserID: userID,
+		}
+	}
+	cart = cloneCart(cart)
+
+	cart.Products = mergeCartItem(cart.Products, item)
+
+	cart.UpdatedAt = time.Now()
+	put(ctx, cr.store.carts, userID, cart)
+	return nil
+}
+
+func (cr *MemoryCartRepository) UpdateCartLine(ctx context.Context, userID primitive.ObjectID, line models.CartItem) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	cart, ok := cr.store.carts[userID]
+	if !ok {
+		return ErrCartLineNotFound
+	}
+	cart = cloneCart(cart)
+
+	products, ok := replaceCartLine(cart.Products, line)
+	if !ok {
+		return ErrCartLineNotFound
+	}
+
+	cart.Products = products
+	cart.UpdatedAt = time.Now()
+	put(ctx, cr.store.carts, userID, cart)
+	return nil
+}
+
+func (cr *MemoryCartRepository) RemoveCartLine(ctx context.Context, userID, lineID primitive.ObjectID) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	cart, ok := cr.store.carts[userID]
+	if !ok || cart.FindLine(lineID) == nil {
+		return ErrCartLineNotFound
+	}
+
+	products := []models.CartItem{}
+	for _, item := range cart.Products {
+		if item.LineID != lineID {
+			products = append(products, item)
+		}
+	}
+
+	cart.Products = products
+	cart.UpdatedAt = time.Now()
+	put(ctx, cr.store.carts, userID, cart)
+	return nil
+}
+
+func (cr *MemoryCartRepository) ClearCart(ctx context.Context, userID primitive.ObjectID) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	cart, ok := cr.store.carts[userID]
+	if !ok {
+		return nil
+	}
+
+	cart.Products = []models.CartItem{}
+	cart.CouponCode = ""
+	cart.UpdatedAt = time.Now()
+	put(ctx, cr.store.carts, userID, cart)
+	return nil
+}
+
+func (cr *MemoryCartRepository) SetCartCoupon(ctx context.Context, userID primitive.ObjectID, code string) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	cart, ok := cr.store.carts[userID]
+	if !ok {
+		return nil
+	}
+
+	cart = cloneCart(cart)
+	cart.CouponCode = code
+	cart.UpdatedAt = time.Now()
+	put(ctx, cr.store.carts, userID, cart)
+	return nil
+}
+
+func (cr *MemoryCartRepository) DeleteCart(ctx context.Context, userID primitive.ObjectID) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	remove(ctx, cr.store.carts, userID)
+	return nil
+}
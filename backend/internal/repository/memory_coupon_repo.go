@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryCouponRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryCouponRepository(store *MemoryStore) *MemoryCouponRepository {
+	return &MemoryCouponRepository{store: store}
+}
+
+func (cr *MemoryCouponRepository) CreateCoupon(ctx context.Context, coupon *models.Coupon) (*models.Coupon, error) {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	if cr.findByCode(coupon.Code) != nil {
+		return nil, ErrDuplicateCouponCode
+	}
+
+	if coupon.ID.IsZero() {
+		coupon.ID = primitive.NewObjectID()
+	}
+	put(ctx, cr.store.coupons, coupon.ID, cloneCoupon(*coupon))
+	return coupon, nil
+}
+
+func (cr *MemoryCouponRepository) GetCouponByID(ctx context.Context, couponID primitive.ObjectID) (*models.Coupon, error) {
+	cr.store.mu.RLock()
+	defer cr.store.mu.RUnlock()
+
+	coupon, ok := cr.store.coupons[couponID]
+	if !ok {
+		return nil, nil
+	}
+
+	coupon = cloneCoupon(coupon)
+	return &coupon, nil
+}
+
+func (cr *MemoryCouponRepository) GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	cr.store.mu.RLock()
+	defer cr.store.mu.RUnlock()
+
+	coupon := cr.findByCode(code)
+	if coupon == nil {
+		return nil, nil
+	}
+
+	cloned := cloneCoupon(*coupon)
+	return &cloned, nil
+}
+
+// findByCode returns the stored coupon with code, or nil. Callers must hold mu.
+func (cr *MemoryCouponRepository) findByCode(code string) *models.Coupon {
+	code = models.NormalizeCouponCode(code)
+	for _, coupon := range cr.store.coupons {
+		if coupon.Code == code {
+			return &coupon
+		}
+	}
+	return nil
+}
+
+func (cr *MemoryCouponRepository) GetAllCoupons(ctx context.Context) ([]models.Coupon, error) {
+	cr.store.mu.RLock()
+	defer cr.store.mu.RUnlock()
+
+	var coupons []models.Coupon
+	for _, coupon := range cr.store.coupons {
+		coupons = append(coupons, cloneCoupon(coupon))
+	}
+
+	sort.Slice(coupons, func(i, j int) bool { return coupons[i].CreatedAt.After(coupons[j].CreatedAt) })
+	return coupons, nil
+}
+
+func (cr *MemoryCouponRepository) UpdateCoupon(ctx context.Context, couponID primitive.ObjectID, coupon *models.Coupon) (*models.Coupon, error) {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	existing, ok := cr.store.coupons[couponID]
+	if !ok {
+		return nil, nil
+	}
+	if other := cr.findByCode(coupon.Code); other != nil && other.ID != couponID {
+		return nil, ErrDuplicateCouponCode
+	}
+
+	updated := cloneCoupon(*coupon)
+	updated.ID = couponID
+	updated.UsedCount = existing.UsedCount
+	updated.CreatedAt = existing.CreatedAt
+	put(ctx, cr.store.coupons, couponID, updated)
+
+	updated = cloneCoupon(updated)
+	return &updated, nil
+}
+
+func (cr *MemoryCouponRepository) DeleteCoupon(ctx context.Context, couponID primitive.ObjectID) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	remove(ctx, cr.store.coupons, couponID)
+	for id, redemption := range cr.store.redemptions {
+		if redemption.CouponID == couponID {
+			remove(ctx, cr.store.redemptions, id)
+		}
+	}
+	return nil
+}
+
+func (cr *MemoryCouponRepository) GetUserRedemptions(ctx context.Context, couponID, userID primitive.ObjectID) (int, error) {
+	cr.store.mu.RLock()
+	defer cr.store.mu.RUnlock()
+
+	if redemption := cr.findRedemption(couponID, userID); redemption != nil {
+		return redemption.Count, nil
+	}
+	return 0, nil
+}
+
+// findRedemption returns the user's uses of the coupon, or nil. Callers must
+// hold mu.
+func (cr *MemoryCouponRepository) findRedemption(couponID, userID primitive.ObjectID) *models.CouponRedemption {
+	for _, redemption := range cr.store.redemptions {
+		if redemption.CouponID == couponID && redemption.UserID == userID {
+			return &redemption
+		}
+	}
+	return nil
+}
+
+func (cr *MemoryCouponRepository) RedeemCoupon(ctx context.Context, coupon *models.Coupon, userID primitive.ObjectID) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	stored, ok := cr.store.coupons[coupon.ID]
+	if !ok || !stored.Active {
+		return ErrCouponUnavailable
+	}
+	if coupon.UsageLimit > 0 && stored.UsedCount >= coupon.UsageLimit {
+		return ErrCouponUnavailable
+	}
+
+	redemption := models.CouponRedemption{ID: primitive.NewObjectID(), CouponID: coupon.ID, UserID: userID}
+	if existing := cr.findRedemption(coupon.ID, userID); existing != nil {
+		redemption = *existing
+	}
+	if coupon.PerUserLimit > 0 && redemption.Count >= coupon.PerUserLimit {
+		return ErrCouponUnavailable
+	}
+
+	now := time.Now()
+	stored = cloneCoupon(stored)
+	stored.UsedCount++
+	stored.UpdatedAt = now
+	put(ctx, cr.store.coupons, coupon.ID, stored)
+
+	redemption.Count++
+	redemption.UpdatedAt = now
+	put(ctx, cr.store.redemptions, redemption.ID, redemption)
+	return nil
+}
+
+func (cr *MemoryCouponRepository) ReleaseCoupon(ctx context.Context, couponID, userID primitive.ObjectID) error {
+	cr.store.mu.Lock()
+	defer cr.store.mu.Unlock()
+
+	coupon, ok := cr.store.coupons[couponID]
+	if !ok || coupon.UsedCount == 0 {
+		return nil
+	}
+	redemption := cr.findRedemption(couponID, userID)
+	if redemption == nil || redemption.Count == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	released := cloneCoupon(coupon)
+	released.UsedCount--
+	released.UpdatedAt = now
+	put(ctx, cr.store.coupons, released.ID, released)
+
+	redemption.Count--
+	redemption.UpdatedAt = now
+	put(ctx, cr.store.redemptions, redemption.ID, *redemption)
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryIdempotencyRepository drops records once they expire, standing in for
+// the TTL index of the MongoDB implementation.
+type MemoryIdempotencyRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryIdempotencyRepository(store *MemoryStore) *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{store: store}
+}
+
+func (ir *MemoryIdempotencyRepository) CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord, now time.Time) (*models.IdempotencyRecord, error) {
+	ir.store.mu.Lock()
+	defer ir.store.mu.Unlock()
+
+	for id, stored := range ir.store.idempotencyKeys {
+		if !now.Before(stored.ExpiresAt) {
+			remove(ctx, ir.store.idempotencyKeys, id)
+		}
+	}
+
+	if ir.find(record.UserID, record.Key, now) != nil {
+		return nil, ErrDuplicateIdempotencyKey
+	}
+
+	if record.ID.IsZero() {
+		record.ID = primitive.NewObjectID()
+	}
+	put(ctx, ir.store.idempotencyKeys, record.ID, cloneIdempotencyRecord(*record))
+	return record, nil
+}
+
+func (ir *MemoryIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, userID, key string, now time.Time) (*models.IdempotencyRecord, error) {
+	ir.store.mu.RLock()
+	defer ir.store.mu.RUnlock()
+
+	record := ir.find(userID, key, now)
+	if record == nil {
+		return nil, nil
+	}
+
+	cloned := cloneIdempotencyRecord(*record)
+	return &cloned, nil
+}
+
+// find returns the user's record for key unless it expired before now.
+// Callers must hold mu.
+func (ir *MemoryIdempotencyRepository) find(userID, key string, now time.Time) *models.IdempotencyRecord {
+	for _, record := range ir.store.idempotencyKeys {
+		if record.UserID == userID && record.Key == key && now.Before(record.ExpiresAt) {
+			return &record
+		}
+	}
+	return nil
+}
+
+func (ir *MemoryIdempotencyRepository) CompleteIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	ir.store.mu.Lock()
+	defer ir.store.mu.Unlock()
+
+	stored, ok := ir.store.idempotencyKeys[record.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = record.Status
+	stored.StatusCode = record.StatusCode
+	stored.ContentType = record.ContentType
+	stored.Body = slices.Clone(record.Body)
+	put(ctx, ir.store.idempotencyKeys, record.ID, stored)
+	return nil
+}
+
+func (ir *MemoryIdempotencyRepository) DeleteIdempotencyRecord(ctx context.Context, recordID primitive.ObjectID) error {
+	ir.store.mu.Lock()
+	defer ir.store.mu.Unlock()
+
+	remove(ctx, ir.store.idempotencyKeys, recordID)
+	return nil
+}
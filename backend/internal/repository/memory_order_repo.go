@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryOrderRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryOrderRepository(store *MemoryStore) *MemoryOrderRepository {
+	return &MemoryOrderRepository{store: store}
+}
+
+func (or *MemoryOrderRepository) CreateOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	put(ctx, or.store.orders, order.ID, cloneOrder(*order))
+	return order, nil
+}
+
+func (or *MemoryOrderRepository) GetOrderByID(ctx context.Context, orderID primitive.ObjectID) (*models.Order, error) {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	order, ok := or.store.orders[orderID]
+	if !ok {
+		return nil, nil
+	}
+
+	order = cloneOrder(order)
+	return &order, nil
+}
+
+func (or *MemoryOrderRepository) GetUserOrders(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error) {
+	return or.filter(func(o models.Order) bool { return o.UserID == userID }), nil
+}
+
+func (or *MemoryOrderRepository) GetAllOrders(ctx context.Context) ([]models.Order, error) {
+	return or.filter(func(models.Order) bool { return true }), nil
+}
+
+func (or *MemoryOrderRepository) ListOrders(ctx context.Context, query models.OrderQuery) ([]models.Order, int64, error) {
+	orders := or.filter(func(o models.Order) bool { return matchesOrderQuery(o, query) })
+	sortOrders(orders, query.Sort)
+
+	total := int64(len(orders))
+	return paginate(orders, query.Page, query.Limit), total, nil
+}
+
+func matchesOrderQuery(o models.Order, query models.OrderQuery) bool {
+	if query.Status != "" && o.Status != query.Status {
+		return false
+	}
+	if query.UserID != nil && o.UserID != *query.UserID {
+		return false
+	}
+	if query.From != nil && o.CreatedAt.Before(*query.From) {
+		return false
+	}
+	if query.To != nil && !o.CreatedAt.Before(*query.To) {
+		return false
+	}
+	if query.MinTotal != nil && o.TotalPrice.Less(*query.MinTotal) {
+		return false
+	}
+	if query.MaxTotal != nil && query.MaxTotal.Less(o.TotalPrice) {
+		return false
+	}
+	return true
+}
+
+func sortOrders(orders []models.Order, sortKey string) {
+	field, desc := strings.TrimPrefix(sortKey, "-"), strings.HasPrefix(sortKey, "-")
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		var order int
+		switch field {
+		case "createdAt":
+			order = a.CreatedAt.Compare(b.CreatedAt)
+		case "updatedAt":
+			order = a.UpdatedAt.Compare(b.UpdatedAt)
+		case "totalPrice":
+			order = cmp.Compare(a.TotalPrice.Amount, b.TotalPrice.Amount)
+		}
+		if desc {
+			order = -order
+		}
+		return order < 0
+	})
+}
+
+func (or *MemoryOrderRepository) TransitionOrderStatus(ctx context.Context, orderID primitive.ObjectID, from string, change models.StatusChange) (*models.Order, error) {
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	order, ok := or.store.orders[orderID]
+	if !ok || order.Status != from {
+		return nil, ErrOrderStatusChanged
+	}
+	order = cloneOrder(order)
+
+	order.Status = change.Status
+	order.UpdatedAt = change.At
+	order.StatusHistory = append(order.StatusHistory, change)
+	if change.Status == models.OrderStatusCancelled {
+		order.CancelReason = change.Note
+	}
+	put(ctx, or.store.orders, orderID, order)
+
+	order = cloneOrder(order)
+	return &order, nil
+}
+
+func (or *MemoryOrderRepository) GetOrdersByStatus(ctx context.Context, status string) ([]models.Order, error) {
+	return or.filter(func(o models.Order) bool { return o.Status == status }), nil
+}
+
+func (or *MemoryOrderRepository) DeleteOrder(ctx context.Context, orderID primitive.ObjectID) error {
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	remove(ctx, or.store.orders, orderID)
+	return nil
+}
+
+func (or *MemoryOrderRepository) GetTotalOrders(ctx context.Context) (int64, error) {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	return int64(len(or.store.orders)), nil
+}
+
+func (or *MemoryOrderRepository) GetTotalRevenue(ctx context.Context) (models.Money, error) {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	revenue := models.NewMoney(0)
+	for _, order := range or.store.orders {
+		if order.Status != models.OrderStatusCancelled {
+			revenue = revenue.Add(order.TotalPrice)
+		}
+	}
+	return revenue, nil
+}
+
+func (or *MemoryOrderRepository) SalesTimeSeries(ctx context.Context, query models.SalesQuery) ([]models.SalesBucket, error) {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	byStart := map[time.Time]*models.SalesBucket{}
+	for _, order := range or.store.orders {
+		if order.Status == models.OrderStatusCancelled || order.CreatedAt.Before(query.From) || !order.CreatedAt.Before(query.To) {
+			continue
+		}
+		start := models.TruncateToInterval(order.CreatedAt, query.Interval)
+		if byStart[start] == nil {
+			byStart[start] = &models.SalesBucket{Start: start}
+		}
+		byStart[start].Revenue = byStart[start].Revenue.Add(order.TotalPrice)
+		byStart[start].Orders++
+	}
+
+	buckets := make([]models.SalesBucket, 0, len(byStart))
+	for _, b := range byStart {
+		buckets = append(buckets, *b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets, nil
+}
+
+// productSales sums units and revenue per product over the non-cancelled
+// orders in the report range. Callers must hold mu.
+func (or *MemoryOrderRepository) productSales(query models.ReportQuery) []models.ProductSales {
+	byProduct := map[primitive.ObjectID]*models.ProductSales{}
+	for _, order := range or.store.orders {
+		if order.Status == models.OrderStatusCancelled || order.CreatedAt.Before(query.From) || !order.CreatedAt.Before(query.To) {
+			continue
+		}
+		for _, item := range order.Products {
+			if byProduct[item.ProductID] == nil {
+				byProduct[item.ProductID] = &models.ProductSales{ProductID: item.ProductID, Name: item.Name}
+			}
+			byProduct[item.ProductID].Units += int64(item.Quantity)
+			byProduct[item.ProductID].Revenue = byProduct[item.ProductID].Revenue.Add(item.Total())
+		}
+	}
+
+	sales := make([]models.ProductSales, 0, len(byProduct))
+	for _, s := range byProduct {
+		sales = append(sales, *s)
+	}
+	sortByID(sales, func(s models.ProductSales) primitive.ObjectID { return s.ProductID })
+	return sales
+}
+
+func (or *MemoryOrderRepository) TopProducts(ctx context.Context, query models.ReportQuery, rankBy string) ([]models.ProductSales, error) {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	sales := or.productSales(query)
+	sort.SliceStable(sales, func(i, j int) bool {
+		if rankBy == models.RankByRevenue {
+			return sales[j].Revenue.Less(sales[i].Revenue)
+		}
+		return sales[i].Units > sales[j].Units
+	})
+	return sales[:min(len(sales), query.Limit)], nil
+}
+
+func (or *MemoryOrderRepository) RevenueByCategory(ctx context.Context, query models.ReportQuery) ([]models.CategorySales, error) {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	byCategory := map[string]*models.CategorySales{}
+	for _, s := range or.productSales(query) {
+		category := models.UncategorizedCategory
+		if product, ok := or.store.products[s.ProductID]; ok {
+			category = product.Category
+		}
+		if byCategory[category] == nil {
+			byCategory[category] = &models.CategorySales{Category: category}
+		}
+		byCategory[category].Units += s.Units
+		byCategory[category].Revenue = byCategory[category].Revenue.Add(s.Revenue)
+	}
+
+	sales := make([]models.CategorySales, 0, len(byCategory))
+	for _, s := range byCategory {
+		sales = append(sales, *s)
+	}
+	sort.Slice(sales, func(i, j int) bool {
+		if sales[i].Revenue.Amount != sales[j].Revenue.Amount {
+			return sales[j].Revenue.Less(sales[i].Revenue)
+		}
+		return sales[i].Category < sales[j].Category
+	})
+	return sales, nil
+}
+
+func (or *MemoryOrderRepository) SellThrough(ctx context.Context, query models.ReportQuery) ([]models.SellThrough, error) {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	rates := []models.SellThrough{}
+	for _, s := range or.productSales(query) {
+		rate := models.SellThrough{ProductID: s.ProductID, Name: s.Name, UnitsSold: s.Units}
+		if product, ok := or.store.products[s.ProductID]; ok {
+			rate.Stock = int64(max(product.Stock, 0))
+		}
+		rate.Rate = float64(rate.UnitsSold) / float64(rate.UnitsSold+rate.Stock)
+		rates = append(rates, rate)
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		if rates[i].Rate != rates[j].Rate {
+			return rates[i].Rate > rates[j].Rate
+		}
+		return rates[i].UnitsSold > rates[j].UnitsSold
+	})
+	return rates[:min(len(rates), query.Limit)], nil
+}
+
+func (or *MemoryOrderRepository) CustomerOrderStats(ctx context.Context) ([]models.CustomerOrderStats, error) {
+	orders := or.filter(func(o models.Order) bool { return o.Status != models.OrderStatusCancelled })
+	sort.SliceStable(orders, func(i, j int) bool { return orders[i].CreatedAt.Before(orders[j].CreatedAt) })
+
+	byUser := map[primitive.ObjectID]*models.CustomerOrderStats{}
+	for _, order := range orders {
+		s := byUser[order.UserID]
+		if s == nil {
+			s = &models.CustomerOrderStats{UserID: order.UserID, FirstOrderAt: order.CreatedAt}
+			byUser[order.UserID] = s
+		} else if s.SecondOrderAt == nil {
+			second := order.CreatedAt
+			s.SecondOrderAt = &second
+		}
+		s.Orders++
+		s.TotalSpent = s.TotalSpent.Add(order.TotalPrice)
+		s.LastOrderAt = order.CreatedAt
+	}
+
+	stats := make([]models.CustomerOrderStats, 0, len(byUser))
+	for _, s := range byUser {
+		stats = append(stats, *s)
+	}
+	sortByID(stats, func(s models.CustomerOrderStats) primitive.ObjectID { return s.UserID })
+	return stats, nil
+}
+
+func (or *MemoryOrderRepository) filter(match func(models.Order) bool) []models.Order {
+	or.store.mu.RLock()
+	defer or.store.mu.RUnlock()
+
+	var orders []models.Order
+	for _, o := range or.store.orders {
+		if match(o) {
+			orders = append(orders, cloneOrder(o))
+		}
+	}
+
+	sortByID(orders, func(o models.Order) primitive.ObjectID { return o.ID })
+	return orders
+}
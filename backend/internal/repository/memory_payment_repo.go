@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryPaymentRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryPaymentRepository(store *MemoryStore) *MemoryPaymentRepository {
+	return &MemoryPaymentRepository{store: store}
+}
+
+func (pr *MemoryPaymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	if payment.ID.IsZero() {
+		payment.ID = primitive.NewObjectID()
+	}
+	put(ctx, pr.store.payments, payment.ID, *payment)
+	return payment, nil
+}
+
+func (pr *MemoryPaymentRepository) GetPaymentByID(ctx context.Context, paymentID primitive.ObjectID) (*models.Payment, error) {
+	pr.store.mu.RLock()
+	defer pr.store.mu.RUnlock()
+
+	payment, ok := pr.store.payments[paymentID]
+	if !ok {
+		return nil, nil
+	}
+	return &payment, nil
+}
+
+func (pr *MemoryPaymentRepository) GetPaymentByOrder(ctx context.Context, orderID primitive.ObjectID) (*models.Payment, error) {
+	return pr.find(func(p models.Payment) bool { return p.OrderID == orderID }), nil
+}
+
+func (pr *MemoryPaymentRepository) GetPaymentByProviderRef(ctx context.Context, provider, ref string) (*models.Payment, error) {
+	return pr.find(func(p models.Payment) bool { return ref != "" && p.Provider == provider && p.ProviderRef == ref }), nil
+}
+
+func (pr *MemoryPaymentRepository) find(match func(models.Payment) bool) *models.Payment {
+	pr.store.mu.RLock()
+	defer pr.store.mu.RUnlock()
+
+	for _, payment := range pr.store.payments {
+		if match(payment) {
+			return &payment
+		}
+	}
+	return nil
+}
+
+func (pr *MemoryPaymentRepository) TransitionPayment(ctx context.Context, from string, payment *models.Payment) (*models.Payment, error) {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	stored, ok := pr.store.payments[payment.ID]
+	if !ok || stored.Status != from {
+		return nil, ErrPaymentStatusChanged
+	}
+
+	stored.Status = payment.Status
+	stored.ProviderRef = payment.ProviderRef
+	stored.ClientSecret = payment.ClientSecret
+	stored.Captured = payment.Captured
+	stored.Refunded = payment.Refunded
+	stored.FailureReason = payment.FailureReason
+	stored.UpdatedAt = payment.UpdatedAt
+	put(ctx, pr.store.payments, payment.ID, stored)
+
+	return &stored, nil
+}
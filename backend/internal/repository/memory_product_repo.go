@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MemoryProductRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryProductRepository(store *MemoryStore) *MemoryProductRepository {
+	return &MemoryProductRepository{store: store}
+}
+
+func (pr *MemoryProductRepository) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	if product.ID.IsZero() {
+		product.ID = primitive.NewObjectID()
+	}
+	put(ctx, pr.store.products, product.ID, cloneProduct(*product))
+	return product, nil
+}
+
+func (pr *MemoryProductRepository) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*models.Product, error) {
+	pr.store.mu.RLock()
+	defer pr.store.mu.RUnlock()
+
+	product, ok := pr.store.products[productID]
+	if !ok {
+		return nil, nil
+	}
+
+	product = cloneProduct(product)
+	return &product, nil
+}
+
+func (pr *MemoryProductRepository) GetAllProducts(ctx context.Context) ([]models.Product, error) {
+	return pr.filter(func(models.Product) bool { return true }), nil
+}
+
+func (pr *MemoryProductRepository) ListProducts(ctx context.Context, query models.ProductQuery) ([]models.Product, int64, error) {
+	products := pr.filter(func(p models.Product) bool { return matchesProductQuery(p, query) })
+	sortProducts(products, query.Sort, query.Now)
+
+	total := int64(len(products))
+	return paginate(products, query.Page, query.Limit), total, nil
+}
+
+func (pr *MemoryProductRepository) ProductFacets(ctx context.Context, query models.ProductQuery) (*models.ProductFacets, error) {
+	products := pr.filter(func(p models.Product) bool { return matchesProductQuery(p, query) })
+
+	categories := make(map[string]int64)
+	sizes := make(map[string]int64)
+	facets := &models.ProductFacets{PriceBuckets: models.NewPriceBuckets()}
+
+	for _, p := range products {
+		categories[p.Category]++
+		for _, size := range p.Size {
+			sizes[size]++
+		}
+
+		for i := len(facets.PriceBuckets) - 1; i >= 0; i-- {
+			if !p.FromPriceAt(query.Now).Less(facets.PriceBuckets[i].Min) {
+				facets.PriceBuckets[i].Count++
+				break
+			}
+		}
+
+		if p.Stock > 0 {
+			facets.InStock++
+		} else {
+			facets.OutOfStock++
+		}
+	}
+
+	facets.Categories = facetCounts(categories)
+	sort.SliceStable(facets.Categories, func(i, j int) bool {
+		return facets.Categories[i].Count > facets.Categories[j].Count
+	})
+	facets.Sizes = facetCounts(sizes)
+
+	return facets, nil
+}
+
+// facetCounts flattens a count map into FacetCounts sorted by value.
+func facetCounts(counts map[string]int64) []models.FacetCount {
+	result := []models.FacetCount{}
+	for value, count := range counts {
+		result = append(result, models.FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Value < result[j].Value })
+	return result
+}
+
+func matchesProductQuery(p models.Product, query models.ProductQuery) bool {
+	if query.Category != "" && p.Category != query.Category {
+		return false
+	}
+	if query.Size != "" && !slices.Contains(p.Size, query.Size) {
+		return false
+	}
+	if query.MinPrice != nil && p.FromPriceAt(query.Now).Less(*query.MinPrice) {
+		return false
+	}
+	if query.MaxPrice != nil && query.MaxPrice.Less(p.FromPriceAt(query.Now)) {
+		return false
+	}
+	if query.InStock != nil && (p.Stock > 0) != *query.InStock {
+		return false
+	}
+	return true
+}
+
+func sortProducts(products []models.Product, sortKey string, now time.Time) {
+	field, desc := strings.TrimPrefix(sortKey, "-"), strings.HasPrefix(sortKey, "-")
+
+	sort.SliceStable(products, func(i, j int) bool {
+		a, b := products[i], products[j]
+		var order int
+		switch field {
+		case "price":
+			order = cmp.Compare(a.FromPriceAt(now).Amount, b.FromPriceAt(now).Amount)
+		case "createdAt":
+			order = a.CreatedAt.Compare(b.CreatedAt)
+		case "name":
+			order = strings.Compare(a.Name, b.Name)
+		}
+		if desc {
+			order = -order
+		}
+		return order < 0
+	})
+}
+
+func (pr *MemoryProductRepository) GetProductsByCategory(ctx context.Context, category string) ([]models.Product, error) {
+	return pr.filter(func(p models.Product) bool { return p.Category == category }), nil
+}
+
+func (pr *MemoryProductRepository) UpdateProduct(ctx context.Context, productID primitive.ObjectID, product *models.Product) (*models.Product, error) {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	existing, ok := pr.store.products[productID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	existing.Name = product.Name
+	existing.Description = product.Description
+	existing.Price = product.Price
+	existing.Size = product.Size
+	existing.Category = product.Category
+	existing.ImageURL = product.ImageURL
+	existing.Stock = product.Stock
+	existing.Weight = product.Weight
+	existing.Variants = product.Variants
+	existing.SalePrice = product.SalePrice
+	existing.SaleStartsAt = product.SaleStartsAt
+	existing.SaleEndsAt = product.SaleEndsAt
+	existing.UpdatedAt = product.UpdatedAt
+
+	existing = cloneProduct(existing)
+	put(ctx, pr.store.products, productID, existing)
+
+	updated := cloneProduct(existing)
+	return &updated, nil
+}
+
+func (pr *MemoryProductRepository) DecrementStock(ctx context.Context, productID primitive.ObjectID, sku string, quantity int) error {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, ok := pr.store.products[productID]
+	if !ok || product.Stock < quantity {
+		return ErrInsufficientStock
+	}
+	product = cloneProduct(product)
+
+	if sku != "" {
+		variant := product.ResolveVariant(sku, "", "")
+		if variant == nil || variant.Stock < quantity {
+			return ErrInsufficientStock
+		}
+		variant.Stock -= quantity
+	}
+
+	product.Stock -= quantity
+	product.UpdatedAt = time.Now()
+	put(ctx, pr.store.products, productID, product)
+	return nil
+}
+
+func (pr *MemoryProductRepository) IncrementStock(ctx context.Context, productID primitive.ObjectID, sku string, quantity int) error {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, ok := pr.store.products[productID]
+	if !ok {
+		return nil
+	}
+	product = cloneProduct(product)
+
+	if sku != "" {
+		variant := product.ResolveVariant(sku, "", "")
+		if variant == nil {
+			return nil
+		}
+		variant.Stock += quantity
+	}
+
+	product.Stock += quantity
+	product.UpdatedAt = time.Now()
+	put(ctx, pr.store.products, productID, product)
+	return nil
+}
+
+func (pr *MemoryProductRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	remove(ctx, pr.store.products, productID)
+	return nil
+}
+
+func (pr *MemoryProductRepository) GetTotalProducts(ctx context.Context) (int64, error) {
+	pr.store.mu.RLock()
+	defer pr.store.mu.RUnlock()
+
+	return int64(len(pr.store.products)), nil
+}
+
+// SearchProducts approximates the MongoDB text index: every query term found in
+// a field adds that field's weight to the product's score.
+func (pr *MemoryProductRepository) SearchProducts(ctx context.Context, query string, page, limit int) ([]models.Product, int64, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	scores := make(map[primitive.ObjectID]int)
+
+	products := pr.filter(func(p models.Product) bool {
+		score := 0
+		for _, term := range terms {
+			score += 10 * strings.Count(strings.ToLower(p.Name), term)
+			score += 5 * strings.Count(strings.ToLower(p.Category), term)
+			score += strings.Count(strings.ToLower(p.Description), term)
+		}
+		scores[p.ID] = score
+		return score > 0
+	})
+
+	sort.SliceStable(products, func(i, j int) bool {
+		return scores[products[i].ID] > scores[products[j].ID]
+	})
+
+	total := int64(len(products))
+	return paginate(products, page, limit), total, nil
+}
+
+func (pr *MemoryProductRepository) filter(match func(models.Product) bool) []models.Product {
+	pr.store.mu.RLock()
+	defer pr.store.mu.RUnlock()
+
+	var products []models.Product
+	for _, p := range pr.store.products {
+		if match(p) {
+			products = append(products, cloneProduct(p))
+		}
+	}
+
+	sortByID(products, func(p models.Product) primitive.ObjectID { return p.ID })
+	return products
+}
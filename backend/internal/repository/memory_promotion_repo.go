@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryPromotionRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryPromotionRepository(store *MemoryStore) *MemoryPromotionRepository {
+	return &MemoryPromotionRepository{store: store}
+}
+
+func (pr *MemoryPromotionRepository) CreatePromotion(ctx context.Context, promotion *models.Promotion) (*models.Promotion, error) {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	if promotion.ID.IsZero() {
+		promotion.ID = primitive.NewObjectID()
+	}
+	put(ctx, pr.store.promotions, promotion.ID, clonePromotion(*promotion))
+	return promotion, nil
+}
+
+func (pr *MemoryPromotionRepository) GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*models.Promotion, error) {
+	pr.store.mu.RLock()
+	defer pr.store.mu.RUnlock()
+
+	promotion, ok := pr.store.promotions[promotionID]
+	if !ok {
+		return nil, nil
+	}
+
+	promotion = clonePromotion(promotion)
+	return &promotion, nil
+}
+
+func (pr *MemoryPromotionRepository) GetAllPromotions(ctx context.Context) ([]models.Promotion, error) {
+	return pr.filter(func(models.Promotion) bool { return true }), nil
+}
+
+func (pr *MemoryPromotionRepository) GetActivePromotions(ctx context.Context, now time.Time) ([]models.Promotion, error) {
+	return pr.filter(func(p models.Promotion) bool { return p.IsLive(now) }), nil
+}
+
+func (pr *MemoryPromotionRepository) UpdatePromotion(ctx context.Context, promotionID primitive.ObjectID, promotion *models.Promotion) (*models.Promotion, error) {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	existing, ok := pr.store.promotions[promotionID]
+	if !ok {
+		return nil, nil
+	}
+
+	updated := clonePromotion(*promotion)
+	updated.ID = promotionID
+	updated.CreatedAt = existing.CreatedAt
+	put(ctx, pr.store.promotions, promotionID, updated)
+
+	updated = clonePromotion(updated)
+	return &updated, nil
+}
+
+func (pr *MemoryPromotionRepository) DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error {
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	remove(ctx, pr.store.promotions, promotionID)
+	return nil
+}
+
+func (pr *MemoryPromotionRepository) filter(match func(models.Promotion) bool) []models.Promotion {
+	pr.store.mu.RLock()
+	defer pr.store.mu.RUnlock()
+
+	var promotions []models.Promotion
+	for _, p := range pr.store.promotions {
+		if match(p) {
+			promotions = append(promotions, clonePromotion(p))
+		}
+	}
+
+	sortByID(promotions, func(p models.Promotion) primitive.ObjectID { return p.ID })
+	sort.SliceStable(promotions, func(i, j int) bool { return promotions[i].Priority > promotions[j].Priority })
+	return promotions
+}
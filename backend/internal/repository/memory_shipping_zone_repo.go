@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryShippingZoneRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryShippingZoneRepository(store *MemoryStore) *MemoryShippingZoneRepository {
+	return &MemoryShippingZoneRepository{store: store}
+}
+
+func (sr *MemoryShippingZoneRepository) CreateShippingZone(ctx context.Context, zone *models.ShippingZone) (*models.ShippingZone, error) {
+	sr.store.mu.Lock()
+	defer sr.store.mu.Unlock()
+
+	if zone.ID.IsZero() {
+		zone.ID = primitive.NewObjectID()
+	}
+	put(ctx, sr.store.shippingZones, zone.ID, cloneShippingZone(*zone))
+	return zone, nil
+}
+
+func (sr *MemoryShippingZoneRepository) GetAllShippingZones(ctx context.Context) ([]models.ShippingZone, error) {
+	return sr.filter(func(models.ShippingZone) bool { return true }), nil
+}
+
+func (sr *MemoryShippingZoneRepository) GetShippingZonesForCountry(ctx context.Context, country string) ([]models.ShippingZone, error) {
+	return sr.filter(func(z models.ShippingZone) bool { return slices.Contains(z.Countries, country) }), nil
+}
+
+func (sr *MemoryShippingZoneRepository) UpdateShippingZone(ctx context.Context, zoneID primitive.ObjectID, zone *models.ShippingZone) (*models.ShippingZone, error) {
+	sr.store.mu.Lock()
+	defer sr.store.mu.Unlock()
+
+	existing, ok := sr.store.shippingZones[zoneID]
+	if !ok {
+		return nil, nil
+	}
+
+	updated := cloneShippingZone(*zone)
+	updated.ID = zoneID
+	updated.CreatedAt = existing.CreatedAt
+	put(ctx, sr.store.shippingZones, zoneID, updated)
+
+	updated = cloneShippingZone(updated)
+	return &updated, nil
+}
+
+func (sr *MemoryShippingZoneRepository) DeleteShippingZone(ctx context.Context, zoneID primitive.ObjectID) error {
+	sr.store.mu.Lock()
+	defer sr.store.mu.Unlock()
+
+	remove(ctx, sr.store.shippingZones, zoneID)
+	return nil
+}
+
+func (sr *MemoryShippingZoneRepository) filter(match func(models.ShippingZone) bool) []models.ShippingZone {
+	sr.store.mu.RLock()
+	defer sr.store.mu.RUnlock()
+
+	var zones []models.ShippingZone
+	for _, z := range sr.store.shippingZones {
+		if match(z) {
+			zones = append(zones, cloneShippingZone(z))
+		}
+	}
+
+	sortByID(zones, func(z models.ShippingZone) primitive.ObjectID { return z.ID })
+	sort.SliceStable(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+	return zones
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStore holds the documents of every in-memory repository. All
+// repositories built on the same store share its data and its lock, and record
+// their writes in the undo log MemoryTransactor rolls a unit of work back with.
+// It is meant for tests and local development: transactions are not isolated
+// from requests outside them.
+type MemoryStore struct {
+	mu              sync.RWMutex
+	txMu            sync.Mutex
+	products        map[primitive.ObjectID]models.Product
+	carts           map[primitive.ObjectID]models.Cart
+	orders          map[primitive.ObjectID]models.Order
+	users           map[primitive.ObjectID]models.User
+	wishlists       map[primitive.ObjectID]models.Wishlist
+	coupons         map[primitive.ObjectID]models.Coupon
+	redemptions     map[primitive.ObjectID]models.CouponRedemption
+	promotions      map[primitive.ObjectID]models.Promotion
+	taxRules        map[primitive.ObjectID]models.TaxRule
+	shippingZones   map[primitive.ObjectID]models.ShippingZone
+	payments        map[primitive.ObjectID]models.Payment
+	webhookEvents   map[primitive.ObjectID]models.WebhookEvent
+	idempotencyKeys map[primitive.ObjectID]models.IdempotencyRecord
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		products:        make(map[primitive.ObjectID]models.Product),
+		carts:           make(map[primitive.ObjectID]models.Cart),
+		orders:          make(map[primitive.ObjectID]models.Order),
+		users:           make(map[primitive.ObjectID]models.User),
+		wishlists:       make(map[primitive.ObjectID]models.Wishlist),
+		coupons:         make(map[primitive.ObjectID]models.Coupon),
+		redemptions:     make(map[primitive.ObjectID]models.CouponRedemption),
+		promotions:      make(map[primitive.ObjectID]models.Promotion),
+		taxRules:        make(map[primitive.ObjectID]models.TaxRule),
+		shippingZones:   make(map[primitive.ObjectID]models.ShippingZone),
+		payments:        make(map[primitive.ObjectID]models.Payment),
+		webhookEvents:   make(map[primitive.ObjectID]models.WebhookEvent),
+		idempotencyKeys: make(map[primitive.ObjectID]models.IdempotencyRecord),
+	}
+}
+
+type MemoryTransactor struct {
+	store *MemoryStore
+}
+
+func NewMemoryTransactor(store *MemoryStore) *MemoryTransactor {
+	return &MemoryTransactor{store: store}
+}
+
+// memoryTx is the undo log of a running transaction: for every document it
+// wrote, a function that puts back what was stored before.
+type memoryTx struct {
+	undo []func()
+}
+
+type memoryTxKey struct{}
+
+// RunInTransaction serializes units of work against the store. When fn fails,
+// the documents it wrote are put back as they were, newest write first;
+// documents it did not touch, including those other requests wrote in the
+// meantime, are left alone. A transaction started inside fn joins it.
+//
+// Requests outside a transaction are not held back by it: they see its writes
+// before it commits, and a rollback undoes their own writes to the documents
+// it touched, such as an admin editing a product being checked out.
+func (mt *MemoryTransactor) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(memoryTxKey{}).(*memoryTx); ok {
+		return fn(ctx)
+	}
+
+	mt.store.txMu.Lock()
+	defer mt.store.txMu.Unlock()
+
+	tx := &memoryTx{}
+	if err := fn(context.WithValue(ctx, memoryTxKey{}, tx)); err != nil {
+		mt.store.mu.Lock()
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+		mt.store.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// put stores doc under id, adding the previous document to the undo log of the
+// transaction running in ctx, if any. Callers must hold mu.
+func put[K comparable, V any](ctx context.Context, collection map[K]V, id K, doc V) {
+	logUndo(ctx, collection, id)
+	collection[id] = doc
+}
+
+// remove deletes the document stored under id, adding it to the undo log of
+// the transaction running in ctx, if any. Callers must hold mu.
+func remove[K comparable, V any](ctx context.Context, collection map[K]V, id K) {
+	logUndo(ctx, collection, id)
+	delete(collection, id)
+}
+
+// logUndo records how to restore the document stored under id. Stored
+// documents are never changed in place, so keeping the value is enough.
+func logUndo[K comparable, V any](ctx context.Context, collection map[K]V, id K) {
+	tx, ok := ctx.Value(memoryTxKey{}).(*memoryTx)
+	if !ok {
+		return
+	}
+	prev, existed := collection[id]
+	tx.undo = append(tx.undo, func() {
+		if existed {
+			collection[id] = prev
+		} else {
+			delete(collection, id)
+		}
+	})
+}
+
+// sortByID orders documents by ObjectID, which matches their insertion order.
+func sortByID[T any](items []T, id func(T) primitive.ObjectID) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := id(items[i]), id(items[j])
+		return a.Hex() < b.Hex()
+	})
+}
+
+// paginate returns the 1-based page of items, or an empty slice past the end.
+func paginate[T any](items []T, page, limit int) []T {
+	start := (page - 1) * limit
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+limit, len(items))]
+}
+
+func cloneProduct(p models.Product) models.Product {
+	p.Size = slices.Clone(p.Size)
+	p.Variants = slices.Clone(p.Variants)
+	return p
+}
+
+func cloneCart(c models.Cart) models.Cart {
+	c.Products = slices.Clone(c.Products)
+	return c
+}
+
+func cloneOrder(o models.Order) models.Order {
+	o.Products = slices.Clone(o.Products)
+	o.StatusHistory = slices.Clone(o.StatusHistory)
+	return o
+}
+
+func cloneWishlist(w models.Wishlist) models.Wishlist {
+	w.Products = slices.Clone(w.Products)
+	return w
+}
+
+func cloneCoupon(c models.Coupon) models.Coupon {
+	c.Categories = slices.Clone(c.Categories)
+	c.ProductIDs = slices.Clone(c.ProductIDs)
+	return c
+}
+
+func clonePromotion(p models.Promotion) models.Promotion {
+	p.Categories = slices.Clone(p.Categories)
+	p.ProductIDs = slices.Clone(p.ProductIDs)
+	return p
+}
+
+func cloneTaxRule(t models.TaxRule) models.TaxRule {
+	t.CategoryRates = maps.Clone(t.CategoryRates)
+	return t
+}
+
+func cloneShippingZone(z models.ShippingZone) models.ShippingZone {
+	z.Countries = slices.Clone(z.Countries)
+	z.PostalPrefixes = slices.Clone(z.PostalPrefixes)
+	z.Methods = slices.Clone(z.Methods)
+	for i := range z.Methods {
+		z.Methods[i].Rates = slices.Clone(z.Methods[i].Rates)
+		if z.Methods[i].FreeOver != nil {
+			freeOver := *z.Methods[i].FreeOver
+			z.Methods[i].FreeOver = &freeOver
+		}
+	}
+	return z
+}
+
+func cloneIdempotencyRecord(r models.IdempotencyRecord) models.IdempotencyRecord {
+	r.Body = slices.Clone(r.Body)
+	return r
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryTransactorRollbackKeepsOtherWrites(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemoryRepositories()
+
+	product, err := repos.Products.CreateProduct(ctx, &models.Product{Name: "Shirt", Stock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherUser := primitive.NewObjectID()
+
+	errAbort := errors.New("abort")
+	err = repos.Tx.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := repos.Products.DecrementStock(txCtx, product.ID, "", 2); err != nil {
+			return err
+		}
+		created, err := repos.Orders.CreateOrder(txCtx, &models.Order{UserID: otherUser})
+		if err != nil {
+			return err
+		}
+
+		// Another request writes outside the transaction meanwhile
+		if err := repos.Carts.AddToCart(ctx, otherUser, models.CartItem{ProductID: product.ID, Quantity: 1}); err != nil {
+			return err
+		}
+
+		if order, _ := repos.Orders.GetOrderByID(txCtx, created.ID); order == nil {
+			t.Error("order not visible inside its transaction")
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("err = %v, want %v", err, errAbort)
+	}
+
+	stored, _ := repos.Products.GetProductByID(ctx, product.ID)
+	if stored.Stock != 5 {
+		t.Errorf("stock = %d after rollback, want 5", stored.Stock)
+	}
+	if orders, _ := repos.Orders.GetUserOrders(ctx, otherUser); len(orders) != 0 {
+		t.Errorf("%d orders after rollback, want none", len(orders))
+	}
+	if cart, _ := repos.Carts.GetCart(ctx, otherUser); len(cart.Products) != 1 {
+		t.Errorf("cart written outside the transaction has %d lines, want 1", len(cart.Products))
+	}
+}
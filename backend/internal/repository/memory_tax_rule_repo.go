@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryTaxRuleRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryTaxRuleRepository(store *MemoryStore) *MemoryTaxRuleRepository {
+	return &MemoryTaxRuleRepository{store: store}
+}
+
+func (tr *MemoryTaxRuleRepository) CreateTaxRule(ctx context.Context, rule *models.TaxRule) (*models.TaxRule, error) {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	if tr.findByDestination(rule.Country, rule.Region) != nil {
+		return nil, ErrDuplicateTaxRule
+	}
+
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+	put(ctx, tr.store.taxRules, rule.ID, cloneTaxRule(*rule))
+	return rule, nil
+}
+
+func (tr *MemoryTaxRuleRepository) GetAllTaxRules(ctx context.Context) ([]models.TaxRule, error) {
+	return tr.filter(func(models.TaxRule) bool { return true }), nil
+}
+
+func (tr *MemoryTaxRuleRepository) GetTaxRulesForCountry(ctx context.Context, country string) ([]models.TaxRule, error) {
+	return tr.filter(func(rule models.TaxRule) bool { return rule.Country == country }), nil
+}
+
+func (tr *MemoryTaxRuleRepository) UpdateTaxRule(ctx context.Context, ruleID primitive.ObjectID, rule *models.TaxRule) (*models.TaxRule, error) {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	existing, ok := tr.store.taxRules[ruleID]
+	if !ok {
+		return nil, nil
+	}
+	if other := tr.findByDestination(rule.Country, rule.Region); other != nil && other.ID != ruleID {
+		return nil, ErrDuplicateTaxRule
+	}
+
+	updated := cloneTaxRule(*rule)
+	updated.ID = ruleID
+	updated.CreatedAt = existing.CreatedAt
+	put(ctx, tr.store.taxRules, ruleID, updated)
+
+	updated = cloneTaxRule(updated)
+	return &updated, nil
+}
+
+func (tr *MemoryTaxRuleRepository) DeleteTaxRule(ctx context.Context, ruleID primitive.ObjectID) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	remove(ctx, tr.store.taxRules, ruleID)
+	return nil
+}
+
+// findByDestination returns the rule for country and region. Callers must hold mu.
+func (tr *MemoryTaxRuleRepository) findByDestination(country, region string) *models.TaxRule {
+	for _, rule := range tr.store.taxRules {
+		if rule.Country == country && rule.Region == region {
+			return &rule
+		}
+	}
+	return nil
+}
+
+func (tr *MemoryTaxRuleRepository) filter(match func(models.TaxRule) bool) []models.TaxRule {
+	tr.store.mu.RLock()
+	defer tr.store.mu.RUnlock()
+
+	var rules []models.TaxRule
+	for _, rule := range tr.store.taxRules {
+		if match(rule) {
+			rules = append(rules, cloneTaxRule(rule))
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Country != rules[j].Country {
+			return rules[i].Country < rules[j].Country
+		}
+		return rules[i].Region < rules[j].Region
+	})
+	return rules
+}
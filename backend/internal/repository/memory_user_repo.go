@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MemoryUserRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryUserRepository(store *MemoryStore) *MemoryUserRepository {
+	return &MemoryUserRepository{store: store}
+}
+
+func (ur *MemoryUserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	ur.store.mu.Lock()
+	defer ur.store.mu.Unlock()
+
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	put(ctx, ur.store.users, user.ID, *user)
+	return user, nil
+}
+
+func (ur *MemoryUserRepository) GetUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+	ur.store.mu.RLock()
+	defer ur.store.mu.RUnlock()
+
+	user, ok := ur.store.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (ur *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ur.store.mu.RLock()
+	defer ur.store.mu.RUnlock()
+
+	for _, user := range ur.store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (ur *MemoryUserRepository) UpdateUser(ctx context.Context, userID primitive.ObjectID, user *models.User) (*models.User, error) {
+	ur.store.mu.Lock()
+	defer ur.store.mu.Unlock()
+
+	existing, ok := ur.store.users[userID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	existing.Name = user.Name
+	existing.Email = user.Email
+	existing.Role = user.Role
+	existing.UpdatedAt = user.UpdatedAt
+	if user.Password != "" {
+		existing.Password = user.Password
+	}
+
+	put(ctx, ur.store.users, userID, existing)
+	return &existing, nil
+}
+
+func (ur *MemoryUserRepository) DeleteUser(ctx context.Context, userID primitive.ObjectID) error {
+	ur.store.mu.Lock()
+	defer ur.store.mu.Unlock()
+
+	remove(ctx, ur.store.users, userID)
+	return nil
+}
+
+func (ur *MemoryUserRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	ur.store.mu.RLock()
+	defer ur.store.mu.RUnlock()
+
+	var users []models.User
+	for _, user := range ur.store.users {
+		users = append(users, user)
+	}
+
+	sortByID(users, func(u models.User) primitive.ObjectID { return u.ID })
+	return users, nil
+}
+
+func (ur *MemoryUserRepository) GetTotalUsers(ctx context.Context) (int64, error) {
+	ur.store.mu.RLock()
+	defer ur.store.mu.RUnlock()
+
+	return int64(len(ur.store.users)), nil
+}
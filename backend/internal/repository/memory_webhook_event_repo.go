@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MemoryWebhookEventRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWebhookEventRepository(store *MemoryStore) *MemoryWebhookEventRepository {
+	return &MemoryWebhookEventRepository{store: store}
+}
+
+func (wr *MemoryWebhookEventRepository) CreateWebhookEvent(ctx context.Context, event *models.WebhookEvent) (*models.WebhookEvent, error) {
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	if wr.find(event.Provider, event.Event.ID) != nil {
+		return nil, ErrDuplicateWebhookEvent
+	}
+
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	put(ctx, wr.store.webhookEvents, event.ID, *event)
+	return event, nil
+}
+
+func (wr *MemoryWebhookEventRepository) GetWebhookEvent(ctx context.Context, provider, eventID string) (*models.WebhookEvent, error) {
+	wr.store.mu.RLock()
+	defer wr.store.mu.RUnlock()
+
+	return wr.find(provider, eventID), nil
+}
+
+// find returns the event the provider sent with eventID. Callers must hold mu.
+func (wr *MemoryWebhookEventRepository) find(provider, eventID string) *models.WebhookEvent {
+	for _, event := range wr.store.webhookEvents {
+		if event.Provider == provider && event.Event.ID == eventID {
+			return &event
+		}
+	}
+	return nil
+}
+
+func (wr *MemoryWebhookEventRepository) GetWebhookEventsByStatus(ctx context.Context, status string) ([]models.WebhookEvent, error) {
+	wr.store.mu.RLock()
+	defer wr.store.mu.RUnlock()
+
+	var events []models.WebhookEvent
+	for _, event := range wr.store.webhookEvents {
+		if event.Status == status {
+			events = append(events, event)
+		}
+	}
+
+	sortByID(events, func(e models.WebhookEvent) primitive.ObjectID { return e.ID })
+	sort.SliceStable(events, func(i, j int) bool { return events[i].ReceivedAt.Before(events[j].ReceivedAt) })
+	return events, nil
+}
+
+func (wr *MemoryWebhookEventRepository) UpdateWebhookEvent(ctx context.Context, event *models.WebhookEvent) error {
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	stored, ok := wr.store.webhookEvents[event.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = event.Status
+	stored.Attempts = event.Attempts
+	stored.LastError = event.LastError
+	stored.ProcessedAt = event.ProcessedAt
+	put(ctx, wr.store.webhookEvents, event.ID, stored)
+	return nil
+}
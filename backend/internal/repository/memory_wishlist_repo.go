@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryWishlistRepository keys wishlists by user ID, like MemoryCartRepository.
+type MemoryWishlistRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWishlistRepository(store *MemoryStore) *MemoryWishlistRepository {
+	return &MemoryWishlistRepository{store: store}
+}
+
+func (wr *MemoryWishlistRepository) GetWishlist(ctx context.Context, userID primitive.ObjectID) (*models.Wishlist, error) {
+	wr.store.mu.RLock()
+	defer wr.store.mu.RUnlock()
+
+	wishlist, ok := wr.store.wishlists[userID]
+	if !ok {
+		return &models.Wishlist{
+			UserID:    userID,
+			Products:  []models.WishlistItem{},
+			UpdatedAt: time.Now(),
+		}, nil
+	}
+
+	wishlist = cloneWishlist(wishlist)
+	return &wishlist, nil
+}
+
+func (wr *MemoryWishlistRepository) AddToWishlist(ctx context.Context, userID, productID primitive.ObjectID) error {
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	wishlist, ok := wr.store.wishlists[userID]
+	if !ok {
+		wishlist = models.Wishlist{
+			ID:     primitive.NewObjectID(),
+			UserID: userID,
+		}
+	}
+
+	for _, item := range wishlist.Products {
+		if item.ProductID == productID {
+			// Product already in wishlist
+			return nil
+		}
+	}
+
+	wishlist = cloneWishlist(wishlist)
+	wishlist.Products = append(wishlist.Products, models.WishlistItem{
+		ProductID: productID,
+		AddedAt:   time.Now(),
+	})
+	wishlist.UpdatedAt = time.Now()
+	put(ctx, wr.store.wishlists, userID, wishlist)
+	return nil
+}
+
+func (wr *MemoryWishlistRepository) RemoveFromWishlist(ctx context.Context, userID, productID primitive.ObjectID) error {
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	wishlist, ok := wr.store.wishlists[userID]
+	if !ok {
+		return nil
+	}
+
+	products := []models.WishlistItem{}
+	for _, item := range wishlist.Products {
+		if item.ProductID != productID {
+			products = append(products, item)
+		}
+	}
+
+	wishlist.Products = products
+	wishlist.UpdatedAt = time.Now()
+	put(ctx, wr.store.wishlists, userID, wishlist)
+	return nil
+}
+
+func (wr *MemoryWishlistRepository) ClearWishlist(ctx context.Context, userID primitive.ObjectID) error {
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	wishlist, ok := wr.store.wishlists[userID]
+	if !ok {
+		return nil
+	}
+
+	wishlist.Products = []models.WishlistItem{}
+	wishlist.UpdatedAt = time.Now()
+	put(ctx, wr.store.wishlists, userID, wishlist)
+	return nil
+}
+
+func (wr *MemoryWishlistRepository) DeleteWishlist(ctx context.Context, userID primitive.ObjectID) error {
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	remove(ctx, wr.store.wishlists, userID)
+	return nil
+}
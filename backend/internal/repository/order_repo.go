@@ -2,26 +2,25 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
-	"github.com/serikkalibeknur/project-clothesstore/config"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type OrderRepository struct {
-	client *mongo.Client
+type MongoOrderRepository struct {
+	collection *mongo.Collection
 }
 
-func NewOrderRepository(client *mongo.Client) *OrderRepository {
-	return &OrderRepository{client: client}
+func NewMongoOrderRepository(db *mongo.Database) *MongoOrderRepository {
+	return &MongoOrderRepository{collection: db.Collection("orders")}
 }
 
-func (or *OrderRepository) CreateOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
-	collection := config.GetCollection(or.client, "orders")
-
-	result, err := collection.InsertOne(ctx, order)
+func (or *MongoOrderRepository) CreateOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	result, err := or.collection.InsertOne(ctx, order)
 	if err != nil {
 		return nil, err
 	}
@@ -30,11 +29,9 @@ func (or *OrderRepository) CreateOrder(ctx context.Context, order *models.Order)
 	return order, nil
 }
 
-func (or *OrderRepository) GetOrderByID(ctx context.Context, orderID primitive.ObjectID) (*models.Order, error) {
-	collection := config.GetCollection(or.client, "orders")
-
+func (or *MongoOrderRepository) GetOrderByID(ctx context.Context, orderID primitive.ObjectID) (*models.Order, error) {
 	var order models.Order
-	err := collection.FindOne(ctx, bson.M{"_id": orderID}).Decode(&order)
+	err := or.collection.FindOne(ctx, bson.M{"_id": orderID}).Decode(&order)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -45,10 +42,8 @@ func (or *OrderRepository) GetOrderByID(ctx context.Context, orderID primitive.O
 	return &order, nil
 }
 
-func (or *OrderRepository) GetUserOrders(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error) {
-	collection := config.GetCollection(or.client, "orders")
-
-	cursor, err := collection.Find(ctx, bson.M{"userID": userID})
+func (or *MongoOrderRepository) GetUserOrders(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error) {
+	cursor, err := or.collection.Find(ctx, bson.M{"userID": userID})
 	if err != nil {
 		return nil, err
 	}
@@ -62,10 +57,8 @@ func (or *OrderRepository) GetUserOrders(ctx context.Context, userID primitive.O
 	return orders, nil
 }
 
-func (or *OrderRepository) GetAllOrders(ctx context.Context) ([]models.Order, error) {
-	collection := config.GetCollection(or.client, "orders")
-
-	cursor, err := collection.Find(ctx, bson.M{})
+func (or *MongoOrderRepository) GetAllOrders(ctx context.Context) ([]models.Order, error) {
+	cursor, err := or.collection.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
@@ -79,16 +72,106 @@ func (or *OrderRepository) GetAllOrders(ctx context.Context) ([]models.Order, er
 	return orders, nil
 }
 
-func (or *OrderRepository) UpdateOrderStatus(ctx context.Context, orderID primitive.ObjectID, status string) (*models.Order, error) {
-	collection := config.GetCollection(or.client, "orders")
+// ListOrders returns one page of orders matching query along with the total
+// number of matches.
+func (or *MongoOrderRepository) ListOrders(ctx context.Context, query models.OrderQuery) ([]models.Order, int64, error) {
+	filter := orderFilter(query)
+
+	total, err := or.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().
+		SetSort(sortSpec(query.Sort)).
+		SetSkip(int64((query.Page - 1) * query.Limit)).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := or.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+func orderFilter(query models.OrderQuery) bson.M {
+	filter := bson.M{}
+
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	if query.UserID != nil {
+		filter["userID"] = *query.UserID
+	}
+
+	createdAt := bson.M{}
+	if query.From != nil {
+		createdAt["$gte"] = *query.From
+	}
+	if query.To != nil {
+		createdAt["$lt"] = *query.To
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	total := bson.M{}
+	if query.MinTotal != nil {
+		total["$gte"] = query.MinTotal.Amount
+	}
+	if query.MaxTotal != nil {
+		total["$lte"] = query.MaxTotal.Amount
+	}
+	if len(total) > 0 {
+		filter["totalPrice.amount"] = total
+	}
+
+	return filter
+}
+
+// EnsureIndexes creates the indexes backing the admin order filters and the
+// customer order history.
+func (or *MongoOrderRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := or.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "totalPrice.amount", Value: 1}}},
+	})
+	return err
+}
+
+// TransitionOrderStatus moves the order from status `from` to change.Status and
+// appends change to its history. The update is conditional on the current
+// status, so concurrent transitions cannot both succeed. For cancellations the
+// change note is also stored as the order's cancel reason.
+func (or *MongoOrderRepository) TransitionOrderStatus(ctx context.Context, orderID primitive.ObjectID, from string, change models.StatusChange) (*models.Order, error) {
+	set := bson.M{"status": change.Status, "updatedAt": change.At}
+	if change.Status == models.OrderStatusCancelled {
+		set["cancelReason"] = change.Note
+	}
 
-	result := collection.FindOneAndUpdate(
+	result := or.collection.FindOneAndUpdate(
 		ctx,
-		bson.M{"_id": orderID},
-		bson.M{"$set": bson.M{"status": status}},
+		bson.M{"_id": orderID, "status": from},
+		bson.M{
+			"$set":  set,
+			"$push": bson.M{"statusHistory": change},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
 	)
 
 	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return nil, ErrOrderStatusChanged
+		}
 		return nil, result.Err()
 	}
 
@@ -100,10 +183,172 @@ func (or *OrderRepository) UpdateOrderStatus(ctx context.Context, orderID primit
 	return &updatedOrder, nil
 }
 
-func (or *OrderRepository) GetOrdersByStatus(ctx context.Context, status string) ([]models.Order, error) {
-	collection := config.GetCollection(or.client, "orders")
+// productSalesStages groups the lines of the non-cancelled orders in the report
+// range by product, summing units and revenue in minor units net of promotion
+// discounts.
+func productSalesStages(query models.ReportQuery) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":    bson.M{"$ne": models.OrderStatusCancelled},
+			"createdAt": bson.M{"$gte": query.From, "$lt": query.To},
+		}}},
+		{{Key: "$unwind", Value: "$products"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$products.productID",
+			"name":  bson.M{"$first": "$products.name"},
+			"units": bson.M{"$sum": "$products.quantity"},
+			"revenue": bson.M{"$sum": bson.M{"$subtract": bson.A{
+				bson.M{"$multiply": bson.A{"$products.price.amount", "$products.quantity"}},
+				bson.M{"$ifNull": bson.A{"$products.discount.amount", 0}},
+			}}},
+		}}},
+	}
+}
+
+// lookupProductStages joins the current product document as "product", keeping
+// rows whose product has since been deleted.
+func lookupProductStages() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{"from": "products", "localField": "_id", "foreignField": "_id", "as": "product"}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$product", "preserveNullAndEmptyArrays": true}}},
+	}
+}
+
+// TopProducts ranks the products sold in the report range by units or revenue.
+func (or *MongoOrderRepository) TopProducts(ctx context.Context, query models.ReportQuery, rankBy string) ([]models.ProductSales, error) {
+	pipeline := append(productSalesStages(query),
+		bson.D{{Key: "$sort", Value: bson.D{{Key: rankBy, Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+	)
+
+	var result []struct {
+		models.ProductSales `bson:",inline"`
+		Revenue             interface{} `bson:"revenue"`
+	}
+	if err := or.aggregate(ctx, pipeline, &result); err != nil {
+		return nil, err
+	}
+
+	sales := make([]models.ProductSales, 0, len(result))
+	for _, row := range result {
+		revenue, ok := numberToMoney(row.Revenue)
+		if !ok {
+			return nil, fmt.Errorf("unexpected revenue type %T", row.Revenue)
+		}
+		row.ProductSales.Revenue = revenue
+		sales = append(sales, row.ProductSales)
+	}
+	return sales, nil
+}
+
+// RevenueByCategory totals units and revenue per product category. Lines of
+// products that no longer exist are reported under "uncategorized".
+func (or *MongoOrderRepository) RevenueByCategory(ctx context.Context, query models.ReportQuery) ([]models.CategorySales, error) {
+	pipeline := append(productSalesStages(query), lookupProductStages()...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"$ifNull": bson.A{"$product.category", models.UncategorizedCategory}},
+			"units":   bson.M{"$sum": "$units"},
+			"revenue": bson.M{"$sum": "$revenue"},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+	)
+
+	var result []struct {
+		models.CategorySales `bson:",inline"`
+		Revenue              interface{} `bson:"revenue"`
+	}
+	if err := or.aggregate(ctx, pipeline, &result); err != nil {
+		return nil, err
+	}
+
+	sales := make([]models.CategorySales, 0, len(result))
+	for _, row := range result {
+		revenue, ok := numberToMoney(row.Revenue)
+		if !ok {
+			return nil, fmt.Errorf("unexpected revenue type %T", row.Revenue)
+		}
+		row.CategorySales.Revenue = revenue
+		sales = append(sales, row.CategorySales)
+	}
+	return sales, nil
+}
+
+// SellThrough returns, for the products sold in the report range, the units
+// sold against the stock left, highest sell-through rate first.
+func (or *MongoOrderRepository) SellThrough(ctx context.Context, query models.ReportQuery) ([]models.SellThrough, error) {
+	pipeline := append(productSalesStages(query), lookupProductStages()...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$set", Value: bson.M{"stock": bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$product.stock", 0}}, 0}}}}},
+		bson.D{{Key: "$set", Value: bson.M{"rate": bson.M{"$divide": bson.A{"$units", bson.M{"$add": bson.A{"$units", "$stock"}}}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "rate", Value: -1}, {Key: "units", Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+	)
+
+	var result []models.SellThrough
+	if err := or.aggregate(ctx, pipeline, &result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = []models.SellThrough{}
+	}
+	return result, nil
+}
+
+// CustomerOrderStats returns order count, lifetime spend and first, second and
+// last order dates for every user with a non-cancelled order.
+func (or *MongoOrderRepository) CustomerOrderStats(ctx context.Context) ([]models.CustomerOrderStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$ne": models.OrderStatusCancelled}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$userID",
+			"orders":      bson.M{"$sum": 1},
+			"totalSpent":  bson.M{"$sum": "$totalPrice.amount"},
+			"orderDates":  bson.M{"$push": "$createdAt"},
+			"lastOrderAt": bson.M{"$max": "$createdAt"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"orders":        1,
+			"totalSpent":    1,
+			"lastOrderAt":   1,
+			"firstOrderAt":  bson.M{"$arrayElemAt": bson.A{"$orderDates", 0}},
+			"secondOrderAt": bson.M{"$arrayElemAt": bson.A{"$orderDates", 1}},
+		}}},
+	}
+
+	var result []struct {
+		models.CustomerOrderStats `bson:",inline"`
+		TotalSpent                interface{} `bson:"totalSpent"`
+	}
+	if err := or.aggregate(ctx, pipeline, &result); err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.CustomerOrderStats, 0, len(result))
+	for _, row := range result {
+		spent, ok := numberToMoney(row.TotalSpent)
+		if !ok {
+			return nil, fmt.Errorf("unexpected total spent type %T", row.TotalSpent)
+		}
+		row.CustomerOrderStats.TotalSpent = spent
+		stats = append(stats, row.CustomerOrderStats)
+	}
+	return stats, nil
+}
+
+func (or *MongoOrderRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, result interface{}) error {
+	cursor, err := or.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, result)
+}
 
-	cursor, err := collection.Find(ctx, bson.M{"status": status})
+func (or *MongoOrderRepository) GetOrdersByStatus(ctx context.Context, status string) ([]models.Order, error) {
+	cursor, err := or.collection.Find(ctx, bson.M{"status": status})
 	if err != nil {
 		return nil, err
 	}
@@ -117,17 +362,13 @@ func (or *OrderRepository) GetOrdersByStatus(ctx context.Context, status string)
 	return orders, nil
 }
 
-func (or *OrderRepository) DeleteOrder(ctx context.Context, orderID primitive.ObjectID) error {
-	collection := config.GetCollection(or.client, "orders")
-
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": orderID})
+func (or *MongoOrderRepository) DeleteOrder(ctx context.Context, orderID primitive.ObjectID) error {
+	_, err := or.collection.DeleteOne(ctx, bson.M{"_id": orderID})
 	return err
 }
 
-func (or *OrderRepository) GetTotalOrders(ctx context.Context) (int64, error) {
-	collection := config.GetCollection(or.client, "orders")
-
-	count, err := collection.CountDocuments(ctx, bson.M{})
+func (or *MongoOrderRepository) GetTotalOrders(ctx context.Context) (int64, error) {
+	count, err := or.collection.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return 0, err
 	}
@@ -135,33 +376,85 @@ func (or *OrderRepository) GetTotalOrders(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
-func (or *OrderRepository) GetTotalRevenue(ctx context.Context) (float64, error) {
-	collection := config.GetCollection(or.client, "orders")
-
+// GetTotalRevenue sums the totals of all orders that were not cancelled.
+func (or *MongoOrderRepository) GetTotalRevenue(ctx context.Context) (models.Money, error) {
 	pipeline := []bson.M{
+		{"$match": bson.M{"status": bson.M{"$ne": models.OrderStatusCancelled}}},
 		{
 			"$group": bson.M{
 				"_id":          nil,
-				"totalRevenue": bson.M{"$sum": "$totalPrice"},
+				"totalRevenue": bson.M{"$sum": "$totalPrice.amount"},
 			},
 		},
 	}
 
-	cursor, err := collection.Aggregate(ctx, pipeline)
+	cursor, err := or.collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 	defer cursor.Close(ctx)
 
 	var result []bson.M
 	if err = cursor.All(ctx, &result); err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
 
 	if len(result) == 0 {
-		return 0, nil
+		return models.NewMoney(0), nil
 	}
 
-	revenue := result[0]["totalRevenue"].(float64)
+	revenue, ok := numberToMoney(result[0]["totalRevenue"])
+	if !ok {
+		return models.Money{}, fmt.Errorf("unexpected revenue type %T", result[0]["totalRevenue"])
+	}
 	return revenue, nil
 }
+
+// SalesTimeSeries groups the non-cancelled orders in the query range into
+// day, week or month buckets with their revenue and order count. Buckets
+// without orders are omitted.
+func (or *MongoOrderRepository) SalesTimeSeries(ctx context.Context, query models.SalesQuery) ([]models.SalesBucket, error) {
+	bucket := bson.M{"date": "$createdAt", "unit": query.Interval}
+	if query.Interval == models.IntervalWeek {
+		bucket["startOfWeek"] = "monday"
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":    bson.M{"$ne": models.OrderStatusCancelled},
+			"createdAt": bson.M{"$gte": query.From, "$lt": query.To},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"$dateTrunc": bucket},
+			"revenue": bson.M{"$sum": "$totalPrice.amount"},
+			"orders":  bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := or.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		models.SalesBucket `bson:",inline"`
+		Revenue            interface{} `bson:"revenue"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.SalesBucket, 0, len(result))
+	for _, row := range result {
+		revenue, ok := numberToMoney(row.Revenue)
+		if !ok {
+			return nil, fmt.Errorf("unexpected revenue type %T", row.Revenue)
+		}
+		row.SalesBucket.Revenue = revenue
+		buckets = append(buckets, row.SalesBucket)
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPaymentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPaymentRepository(db *mongo.Database) *MongoPaymentRepository {
+	return &MongoPaymentRepository{collection: db.Collection("payments")}
+}
+
+func (pr *MongoPaymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	result, err := pr.collection.InsertOne(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.ID = result.InsertedID.(primitive.ObjectID)
+	return payment, nil
+}
+
+func (pr *MongoPaymentRepository) GetPaymentByID(ctx context.Context, paymentID primitive.ObjectID) (*models.Payment, error) {
+	return pr.findOne(ctx, bson.M{"_id": paymentID})
+}
+
+func (pr *MongoPaymentRepository) GetPaymentByOrder(ctx context.Context, orderID primitive.ObjectID) (*models.Payment, error) {
+	return pr.findOne(ctx, bson.M{"orderID": orderID})
+}
+
+func (pr *MongoPaymentRepository) GetPaymentByProviderRef(ctx context.Context, provider, ref string) (*models.Payment, error) {
+	return pr.findOne(ctx, bson.M{"provider": provider, "providerRef": ref})
+}
+
+func (pr *MongoPaymentRepository) findOne(ctx context.Context, filter bson.M) (*models.Payment, error) {
+	var payment models.Payment
+	err := pr.collection.FindOne(ctx, filter).Decode(&payment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// TransitionPayment stores the status, provider reference and amounts of
+// payment, provided the stored payment is still in status from.
+func (pr *MongoPaymentRepository) TransitionPayment(ctx context.Context, from string, payment *models.Payment) (*models.Payment, error) {
+	result := pr.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": payment.ID, "status": from},
+		bson.M{"$set": bson.M{
+			"status":        payment.Status,
+			"providerRef":   payment.ProviderRef,
+			"clientSecret":  payment.ClientSecret,
+			"captured":      payment.Captured,
+			"refunded":      payment.Refunded,
+			"failureReason": payment.FailureReason,
+			"updatedAt":     payment.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return nil, ErrPaymentStatusChanged
+		}
+		return nil, result.Err()
+	}
+
+	var updatedPayment models.Payment
+	if err := result.Decode(&updatedPayment); err != nil {
+		return nil, err
+	}
+
+	return &updatedPayment, nil
+}
+
+// EnsureIndexes allows one payment per order and per provider reference.
+// Payments the provider has not seen yet have no reference and are left out
+// of the second index.
+func (pr *MongoPaymentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := pr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "orderID", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "providerRef", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"providerRef": bson.M{"$type": "string"}}),
+		},
+	})
+	return err
+}
@@ -2,26 +2,27 @@ package repository
 
 import (
 	"context"
+	"math"
+	"strings"
+	"time"
 
-	"github.com/serikkalibeknur/project-clothesstore/config"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type ProductRepository struct {
-	client *mongo.Client
+type MongoProductRepository struct {
+	collection *mongo.Collection
 }
 
-func NewProductRepository(client *mongo.Client) *ProductRepository {
-	return &ProductRepository{client: client}
+func NewMongoProductRepository(db *mongo.Database) *MongoProductRepository {
+	return &MongoProductRepository{collection: db.Collection("products")}
 }
 
-func (pr *ProductRepository) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
-	collection := config.GetCollection(pr.client, "products")
-
-	result, err := collection.InsertOne(ctx, product)
+func (pr *MongoProductRepository) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	result, err := pr.collection.InsertOne(ctx, product)
 	if err != nil {
 		return nil, err
 	}
@@ -30,11 +31,9 @@ func (pr *ProductRepository) CreateProduct(ctx context.Context, product *models.
 	return product, nil
 }
 
-func (pr *ProductRepository) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*models.Product, error) {
-	collection := config.GetCollection(pr.client, "products")
-
+func (pr *MongoProductRepository) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*models.Product, error) {
 	var product models.Product
-	err := collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	err := pr.collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -45,10 +44,8 @@ func (pr *ProductRepository) GetProductByID(ctx context.Context, productID primi
 	return &product, nil
 }
 
-func (pr *ProductRepository) GetAllProducts(ctx context.Context) ([]models.Product, error) {
-	collection := config.GetCollection(pr.client, "products")
-
-	cursor, err := collection.Find(ctx, bson.M{})
+func (pr *MongoProductRepository) GetAllProducts(ctx context.Context) ([]models.Product, error) {
+	cursor, err := pr.collection.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
@@ -62,10 +59,224 @@ func (pr *ProductRepository) GetAllProducts(ctx context.Context) ([]models.Produ
 	return products, nil
 }
 
-func (pr *ProductRepository) GetProductsByCategory(ctx context.Context, category string) ([]models.Product, error) {
-	collection := config.GetCollection(pr.client, "products")
+// ListProducts returns one page of products matching query along with the
+// total number of matches. Filtering, sorting and paging all run in MongoDB,
+// with price filters and sorting applied to the from price at query.Now.
+func (pr *MongoProductRepository) ListProducts(ctx context.Context, query models.ProductQuery) ([]models.Product, int64, error) {
+	pipeline := append(productMatchStages(query), bson.D{{Key: "$facet", Value: bson.M{
+		"items": bson.A{
+			bson.M{"$sort": sortSpec(productSortKey(query.Sort))},
+			bson.M{"$skip": int64((query.Page - 1) * query.Limit)},
+			bson.M{"$limit": int64(query.Limit)},
+		},
+		"total": bson.A{bson.M{"$count": "count"}},
+	}}})
+
+	cursor, err := pr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Items []models.Product `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+	if len(result) == 0 || len(result[0].Total) == 0 {
+		return nil, 0, nil
+	}
+
+	return result[0].Items, result[0].Total[0].Count, nil
+}
+
+// ProductFacets computes category, size, price bucket and stock counts for the
+// products matching query in a single $facet aggregation.
+func (pr *MongoProductRepository) ProductFacets(ctx context.Context, query models.ProductQuery) (*models.ProductFacets, error) {
+	boundaries := bson.A{}
+	for _, lower := range models.PriceBucketBounds {
+		boundaries = append(boundaries, lower)
+	}
+	boundaries = append(boundaries, int64(math.MaxInt64))
+
+	countBy := func(field interface{}) bson.M {
+		return bson.M{"$group": bson.M{"_id": field, "count": bson.M{"$sum": 1}}}
+	}
+
+	pipeline := append(productMatchStages(query), bson.D{{Key: "$facet", Value: bson.M{
+		"categories": bson.A{countBy("$category"), bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		"sizes":      bson.A{bson.M{"$unwind": "$size"}, countBy("$size"), bson.M{"$sort": bson.M{"_id": 1}}},
+		"prices": bson.A{bson.M{"$bucket": bson.M{
+			"groupBy":    "$fromPrice",
+			"boundaries": boundaries,
+			"default":    "other",
+			"output":     bson.M{"count": bson.M{"$sum": 1}},
+		}}},
+		"stock": bson.A{countBy(bson.M{"$gt": bson.A{"$stock", 0}})},
+	}}})
+
+	cursor, err := pr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Categories []models.FacetCount `bson:"categories"`
+		Sizes      []models.FacetCount `bson:"sizes"`
+		Prices     []struct {
+			Lower interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		} `bson:"prices"`
+		Stock []struct {
+			InStock bool  `bson:"_id"`
+			Count   int64 `bson:"count"`
+		} `bson:"stock"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	facets := &models.ProductFacets{
+		Categories:   []models.FacetCount{},
+		Sizes:        []models.FacetCount{},
+		PriceBuckets: models.NewPriceBuckets(),
+	}
+	if len(result) == 0 {
+		return facets, nil
+	}
+
+	if result[0].Categories != nil {
+		facets.Categories = result[0].Categories
+	}
+	if result[0].Sizes != nil {
+		facets.Sizes = result[0].Sizes
+	}
+	for _, bucket := range result[0].Prices {
+		lower, ok := numberToFloat(bucket.Lower)
+		if !ok {
+			continue
+		}
+		for i := range facets.PriceBuckets {
+			if float64(facets.PriceBuckets[i].Min.Amount) == lower {
+				facets.PriceBuckets[i].Count = bucket.Count
+			}
+		}
+	}
+	for _, stock := range result[0].Stock {
+		if stock.InStock {
+			facets.InStock = stock.Count
+		} else {
+			facets.OutOfStock = stock.Count
+		}
+	}
+
+	return facets, nil
+}
+
+// productMatchStages filters the catalog by query. The plain field filters
+// run first so they can use indexes; the price range is then applied to the
+// fromPrice field added in between.
+func productMatchStages(query models.ProductQuery) mongo.Pipeline {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: productFilter(query)}},
+		{{Key: "$addFields", Value: bson.M{"fromPrice": fromPriceExpr(query.Now)}}},
+	}
+
+	price := bson.M{}
+	if query.MinPrice != nil {
+		price["$gte"] = query.MinPrice.Amount
+	}
+	if query.MaxPrice != nil {
+		price["$lte"] = query.MaxPrice.Amount
+	}
+	if len(price) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"fromPrice": price}}})
+	}
+
+	return pipeline
+}
+
+// fromPriceExpr mirrors models.Product.FromPriceAt, as an amount in minor
+// units: the cheapest variant's price, or the product's, with a sale running
+// at now applied.
+func fromPriceExpr(now time.Time) bson.M {
+	isSet := func(field string) bson.M {
+		return bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{field, nil}}, nil}}
+	}
+
+	onSale := bson.M{"$and": bson.A{
+		isSet("$salePrice"),
+		bson.M{"$or": bson.A{bson.M{"$not": bson.A{isSet("$saleStartsAt")}}, bson.M{"$lte": bson.A{"$saleStartsAt", now}}}},
+		bson.M{"$or": bson.A{bson.M{"$not": bson.A{isSet("$saleEndsAt")}}, bson.M{"$gt": bson.A{"$saleEndsAt", now}}}},
+	}}
+	productPrice := bson.M{"$cond": bson.A{onSale, "$salePrice.amount", "$price.amount"}}
+	variantPrice := bson.M{"$cond": bson.A{
+		isSet("$$variant.price"),
+		bson.M{"$cond": bson.A{
+			onSale,
+			bson.M{"$min": bson.A{"$salePrice.amount", "$$variant.price.amount"}},
+			"$$variant.price.amount",
+		}},
+		productPrice,
+	}}
+
+	return bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$variants", bson.A{}}}}, 0}},
+		bson.M{"$min": bson.M{"$map": bson.M{"input": "$variants", "as": "variant", "in": variantPrice}}},
+		productPrice,
+	}}
+}
+
+func productFilter(query models.ProductQuery) bson.M {
+	filter := bson.M{}
 
-	cursor, err := collection.Find(ctx, bson.M{"category": category})
+	if query.Category != "" {
+		filter["category"] = query.Category
+	}
+	if query.Size != "" {
+		filter["size"] = query.Size
+	}
+
+	if query.InStock != nil {
+		if *query.InStock {
+			filter["stock"] = bson.M{"$gt": 0}
+		} else {
+			filter["stock"] = bson.M{"$lte": 0}
+		}
+	}
+
+	return filter
+}
+
+// productSortKey sorts listings by their from price when asked for price.
+func productSortKey(sort string) string {
+	if strings.TrimPrefix(sort, "-") == "price" {
+		return strings.TrimSuffix(sort, "price") + "fromPrice"
+	}
+	return sort
+}
+
+// sortSpec translates a "-field" style sort key into a MongoDB sort document.
+// _id is always appended so pages stay stable when sort values tie.
+func sortSpec(sort string) bson.D {
+	field, direction := strings.TrimPrefix(sort, "-"), 1
+	if strings.HasPrefix(sort, "-") {
+		direction = -1
+	}
+
+	if field == "" {
+		return bson.D{{Key: "_id", Value: 1}}
+	}
+	return bson.D{{Key: field, Value: direction}, {Key: "_id", Value: 1}}
+}
+
+func (pr *MongoProductRepository) GetProductsByCategory(ctx context.Context, category string) ([]models.Product, error) {
+	cursor, err := pr.collection.Find(ctx, bson.M{"category": category})
 	if err != nil {
 		return nil, err
 	}
@@ -79,24 +290,28 @@ func (pr *ProductRepository) GetProductsByCategory(ctx context.Context, category
 	return products, nil
 }
 
-func (pr *ProductRepository) UpdateProduct(ctx context.Context, productID primitive.ObjectID, product *models.Product) (*models.Product, error) {
-	collection := config.GetCollection(pr.client, "products")
-
+func (pr *MongoProductRepository) UpdateProduct(ctx context.Context, productID primitive.ObjectID, product *models.Product) (*models.Product, error) {
 	updateData := bson.M{
-		"name":        product.Name,
-		"description": product.Description,
-		"price":       product.Price,
-		"size":        product.Size,
-		"category":    product.Category,
-		"imageURL":    product.ImageURL,
-		"stock":       product.Stock,
-		"updatedAt":   product.UpdatedAt,
+		"name":         product.Name,
+		"description":  product.Description,
+		"price":        product.Price,
+		"size":         product.Size,
+		"category":     product.Category,
+		"imageURL":     product.ImageURL,
+		"stock":        product.Stock,
+		"weight":       product.Weight,
+		"variants":     product.Variants,
+		"salePrice":    product.SalePrice,
+		"saleStartsAt": product.SaleStartsAt,
+		"saleEndsAt":   product.SaleEndsAt,
+		"updatedAt":    product.UpdatedAt,
 	}
 
-	result := collection.FindOneAndUpdate(
+	result := pr.collection.FindOneAndUpdate(
 		ctx,
 		bson.M{"_id": productID},
 		bson.M{"$set": updateData},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
 	)
 
 	if result.Err() != nil {
@@ -111,17 +326,65 @@ func (pr *ProductRepository) UpdateProduct(ctx context.Context, productID primit
 	return &updatedProduct, nil
 }
 
-func (pr *ProductRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
-	collection := config.GetCollection(pr.client, "products")
+// DecrementStock atomically takes quantity units from the product's stock, or
+// from the variant identified by sku when one is given. The update only matches
+// while enough stock remains, so it never goes below zero.
+func (pr *MongoProductRepository) DecrementStock(ctx context.Context, productID primitive.ObjectID, sku string, quantity int) error {
+	filter := bson.M{"_id": productID, "stock": bson.M{"$gte": quantity}}
+	inc := bson.M{"stock": -quantity}
+	if sku != "" {
+		filter["variants"] = bson.M{"$elemMatch": bson.M{"sku": sku, "stock": bson.M{"$gte": quantity}}}
+		inc["variants.$.stock"] = -quantity
+	}
 
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": productID})
+	result, err := pr.collection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$inc": inc,
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+
+	return nil
+}
+
+// IncrementStock returns quantity units to the product's stock, or to the
+// variant identified by sku. Products or variants that no longer exist are
+// skipped, since there is nothing left to restock.
+func (pr *MongoProductRepository) IncrementStock(ctx context.Context, productID primitive.ObjectID, sku string, quantity int) error {
+	filter := bson.M{"_id": productID}
+	inc := bson.M{"stock": quantity}
+	if sku != "" {
+		filter["variants.sku"] = sku
+		inc["variants.$.stock"] = quantity
+	}
+
+	_, err := pr.collection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$inc": inc,
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
 	return err
 }
 
-func (pr *ProductRepository) GetTotalProducts(ctx context.Context) (int64, error) {
-	collection := config.GetCollection(pr.client, "products")
+func (pr *MongoProductRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	_, err := pr.collection.DeleteOne(ctx, bson.M{"_id": productID})
+	return err
+}
 
-	count, err := collection.CountDocuments(ctx, bson.M{})
+func (pr *MongoProductRepository) GetTotalProducts(ctx context.Context) (int64, error) {
+	count, err := pr.collection.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return 0, err
 	}
@@ -129,25 +392,63 @@ func (pr *ProductRepository) GetTotalProducts(ctx context.Context) (int64, error
 	return count, nil
 }
 
-func (pr *ProductRepository) SearchProducts(ctx context.Context, query string) ([]models.Product, error) {
-	collection := config.GetCollection(pr.client, "products")
+// SearchProducts runs a full-text search over name, description and category
+// and returns one page of matches ranked by relevance.
+func (pr *MongoProductRepository) SearchProducts(ctx context.Context, query string, page, limit int) ([]models.Product, int64, error) {
+	filter := bson.M{"$text": bson.M{"$search": escapeTextSearch(query)}}
 
-	cursor, err := collection.Find(ctx, bson.M{
-		"$or": []bson.M{
-			{"name": bson.M{"$regex": query, "$options": "i"}},
-			{"description": bson.M{"$regex": query, "$options": "i"}},
-			{"category": bson.M{"$regex": query, "$options": "i"}},
-		},
-	})
+	total, err := pr.collection.CountDocuments(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := pr.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer cursor.Close(ctx)
 
 	var products []models.Product
 	if err = cursor.All(ctx, &products); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return products, nil
+	return products, total, nil
+}
+
+// EnsureIndexes creates the text index used by SearchProducts and the indexes
+// backing the catalog filters.
+func (pr *MongoProductRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := pr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "description", Value: "text"},
+				{Key: "category", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("product_text").
+				SetWeights(bson.M{"name": 10, "category": 5, "description": 1}),
+		},
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "price.amount", Value: 1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+	})
+	return err
+}
+
+// escapeTextSearch turns raw user input into plain search terms. Quotes and
+// leading hyphens would otherwise act as phrase and negation operators.
+func escapeTextSearch(query string) string {
+	var terms []string
+	for _, term := range strings.Fields(strings.NewReplacer(`"`, " ", `\`, " ").Replace(query)) {
+		if term = strings.TrimLeft(term, "-"); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " ")
 }
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPromotionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPromotionRepository(db *mongo.Database) *MongoPromotionRepository {
+	return &MongoPromotionRepository{collection: db.Collection("promotions")}
+}
+
+func (pr *MongoPromotionRepository) CreatePromotion(ctx context.Context, promotion *models.Promotion) (*models.Promotion, error) {
+	result, err := pr.collection.InsertOne(ctx, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion.ID = result.InsertedID.(primitive.ObjectID)
+	return promotion, nil
+}
+
+func (pr *MongoPromotionRepository) GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := pr.collection.FindOne(ctx, bson.M{"_id": promotionID}).Decode(&promotion)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (pr *MongoPromotionRepository) GetAllPromotions(ctx context.Context) ([]models.Promotion, error) {
+	return pr.find(ctx, bson.M{})
+}
+
+// GetActivePromotions returns the promotions that are active and scheduled at
+// now, highest priority first.
+func (pr *MongoPromotionRepository) GetActivePromotions(ctx context.Context, now time.Time) ([]models.Promotion, error) {
+	return pr.find(ctx, bson.M{
+		"active": true,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"startsAt": bson.M{"$exists": false}}, bson.M{"startsAt": bson.M{"$lte": now}}}},
+			bson.M{"$or": bson.A{bson.M{"endsAt": bson.M{"$exists": false}}, bson.M{"endsAt": bson.M{"$gt": now}}}},
+		},
+	})
+}
+
+func (pr *MongoPromotionRepository) find(ctx context.Context, filter bson.M) ([]models.Promotion, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := pr.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promotions []models.Promotion
+	if err = cursor.All(ctx, &promotions); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (pr *MongoPromotionRepository) UpdatePromotion(ctx context.Context, promotionID primitive.ObjectID, promotion *models.Promotion) (*models.Promotion, error) {
+	updateData := bson.M{
+		"name":          promotion.Name,
+		"type":          promotion.Type,
+		"priority":      promotion.Priority,
+		"active":        promotion.Active,
+		"startsAt":      promotion.StartsAt,
+		"endsAt":        promotion.EndsAt,
+		"minSubtotal":   promotion.MinSubtotal,
+		"categories":    promotion.Categories,
+		"productIDs":    promotion.ProductIDs,
+		"percent":       promotion.Percent,
+		"buyQuantity":   promotion.BuyQuantity,
+		"freeQuantity":  promotion.FreeQuantity,
+		"freeProductID": promotion.FreeProductID,
+		"updatedAt":     promotion.UpdatedAt,
+	}
+
+	// Unset schedule bounds rather than storing null, so GetActivePromotions
+	// can treat a missing field as "no bound"
+	unset := bson.M{}
+	for field, value := range map[string]*time.Time{"startsAt": promotion.StartsAt, "endsAt": promotion.EndsAt} {
+		if value == nil {
+			delete(updateData, field)
+			unset[field] = ""
+		}
+	}
+	update := bson.M{"$set": updateData}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result := pr.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": promotionID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if err := result.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var updatedPromotion models.Promotion
+	if err := result.Decode(&updatedPromotion); err != nil {
+		return nil, err
+	}
+
+	return &updatedPromotion, nil
+}
+
+func (pr *MongoPromotionRepository) DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error {
+	_, err := pr.collection.DeleteOne(ctx, bson.M{"_id": promotionID})
+	return err
+}
+
+// EnsureIndexes creates the index backing GetActivePromotions.
+func (pr *MongoPromotionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := pr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "active", Value: 1}, {Key: "priority", Value: -1}},
+	})
+	return err
+}
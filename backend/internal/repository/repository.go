@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ProductRepository interface {
+	CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error)
+	GetProductByID(ctx context.Context, productID primitive.ObjectID) (*models.Product, error)
+	GetAllProducts(ctx context.Context) ([]models.Product, error)
+	ListProducts(ctx context.Context, query models.ProductQuery) ([]models.Product, int64, error)
+	ProductFacets(ctx context.Context, query models.ProductQuery) (*models.ProductFacets, error)
+	GetProductsByCategory(ctx context.Context, category string) ([]models.Product, error)
+	UpdateProduct(ctx context.Context, productID primitive.ObjectID, product *models.Product) (*models.Product, error)
+	DecrementStock(ctx context.Context, productID primitive.ObjectID, sku string, quantity int) error
+	IncrementStock(ctx context.Context, productID primitive.ObjectID, sku string, quantity int) error
+	DeleteProduct(ctx context.Context, productID primitive.ObjectID) error
+	GetTotalProducts(ctx context.Context) (int64, error)
+	SearchProducts(ctx context.Context, query string, page, limit int) ([]models.Product, int64, error)
+}
+
+type CartRepository interface {
+	GetCart(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error)
+	AddToCart(ctx context.Context, userID primitive.ObjectID, item models.CartItem) error
+	UpdateCartLine(ctx context.Context, userID primitive.ObjectID, line models.CartItem) error
+	RemoveCartLine(ctx context.Context, userID, lineID primitive.ObjectID) error
+	SetCartCoupon(ctx context.Context, userID primitive.ObjectID, code string) error
+	ClearCart(ctx context.Context, userID primitive.ObjectID) error
+	DeleteCart(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type OrderRepository interface {
+	CreateOrder(ctx context.Context, order *models.Order) (*models.Order, error)
+	GetOrderByID(ctx context.Context, orderID primitive.ObjectID) (*models.Order, error)
+	GetUserOrders(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error)
+	GetAllOrders(ctx context.Context) ([]models.Order, error)
+	ListOrders(ctx context.Context, query models.OrderQuery) ([]models.Order, int64, error)
+	TransitionOrderStatus(ctx context.Context, orderID primitive.ObjectID, from string, change models.StatusChange) (*models.Order, error)
+	GetOrdersByStatus(ctx context.Context, status string) ([]models.Order, error)
+	DeleteOrder(ctx context.Context, orderID primitive.ObjectID) error
+	GetTotalOrders(ctx context.Context) (int64, error)
+	GetTotalRevenue(ctx context.Context) (models.Money, error)
+	SalesTimeSeries(ctx context.Context, query models.SalesQuery) ([]models.SalesBucket, error)
+	TopProducts(ctx context.Context, query models.ReportQuery, rankBy string) ([]models.ProductSales, error)
+	RevenueByCategory(ctx context.Context, query models.ReportQuery) ([]models.CategorySales, error)
+	SellThrough(ctx context.Context, query models.ReportQuery) ([]models.SellThrough, error)
+	CustomerOrderStats(ctx context.Context) ([]models.CustomerOrderStats, error)
+}
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdateUser(ctx context.Context, userID primitive.ObjectID, user *models.User) (*models.User, error)
+	DeleteUser(ctx context.Context, userID primitive.ObjectID) error
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	GetTotalUsers(ctx context.Context) (int64, error)
+}
+
+type WishlistRepository interface {
+	GetWishlist(ctx context.Context, userID primitive.ObjectID) (*models.Wishlist, error)
+	AddToWishlist(ctx context.Context, userID, productID primitive.ObjectID) error
+	RemoveFromWishlist(ctx context.Context, userID, productID primitive.ObjectID) error
+	ClearWishlist(ctx context.Context, userID primitive.ObjectID) error
+	DeleteWishlist(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type CouponRepository interface {
+	CreateCoupon(ctx context.Context, coupon *models.Coupon) (*models.Coupon, error)
+	GetCouponByID(ctx context.Context, couponID primitive.ObjectID) (*models.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error)
+	GetAllCoupons(ctx context.Context) ([]models.Coupon, error)
+	UpdateCoupon(ctx context.Context, couponID primitive.ObjectID, coupon *models.Coupon) (*models.Coupon, error)
+	DeleteCoupon(ctx context.Context, couponID primitive.ObjectID) error
+	GetUserRedemptions(ctx context.Context, couponID, userID primitive.ObjectID) (int, error)
+	RedeemCoupon(ctx context.Context, coupon *models.Coupon, userID primitive.ObjectID) error
+	ReleaseCoupon(ctx context.Context, couponID, userID primitive.ObjectID) error
+}
+
+type PromotionRepository interface {
+	CreatePromotion(ctx context.Context, promotion *models.Promotion) (*models.Promotion, error)
+	GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*models.Promotion, error)
+	GetAllPromotions(ctx context.Context) ([]models.Promotion, error)
+	GetActivePromotions(ctx context.Context, now time.Time) ([]models.Promotion, error)
+	UpdatePromotion(ctx context.Context, promotionID primitive.ObjectID, promotion *models.Promotion) (*models.Promotion, error)
+	DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error
+}
+
+type TaxRuleRepository interface {
+	CreateTaxRule(ctx context.Context, rule *models.TaxRule) (*models.TaxRule, error)
+	GetAllTaxRules(ctx context.Context) ([]models.TaxRule, error)
+	GetTaxRulesForCountry(ctx context.Context, country string) ([]models.TaxRule, error)
+	UpdateTaxRule(ctx context.Context, ruleID primitive.ObjectID, rule *models.TaxRule) (*models.TaxRule, error)
+	DeleteTaxRule(ctx context.Context, ruleID primitive.ObjectID) error
+}
+
+type ShippingZoneRepository interface {
+	CreateShippingZone(ctx context.Context, zone *models.ShippingZone) (*models.ShippingZone, error)
+	GetAllShippingZones(ctx context.Context) ([]models.ShippingZone, error)
+	GetShippingZonesForCountry(ctx context.Context, country string) ([]models.ShippingZone, error)
+	UpdateShippingZone(ctx context.Context, zoneID primitive.ObjectID, zone *models.ShippingZone) (*models.ShippingZone, error)
+	DeleteShippingZone(ctx context.Context, zoneID primitive.ObjectID) error
+}
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment *models.Payment) (*models.Payment, error)
+	GetPaymentByID(ctx context.Context, paymentID primitive.ObjectID) (*models.Payment, error)
+	GetPaymentByOrder(ctx context.Context, orderID primitive.ObjectID) (*models.Payment, error)
+	GetPaymentByProviderRef(ctx context.Context, provider, ref string) (*models.Payment, error)
+	TransitionPayment(ctx context.Context, from string, payment *models.Payment) (*models.Payment, error)
+}
+
+type WebhookEventRepository interface {
+	CreateWebhookEvent(ctx context.Context, event *models.WebhookEvent) (*models.WebhookEvent, error)
+	GetWebhookEvent(ctx context.Context, provider, eventID string) (*models.WebhookEvent, error)
+	GetWebhookEventsByStatus(ctx context.Context, status string) ([]models.WebhookEvent, error)
+	UpdateWebhookEvent(ctx context.Context, event *models.WebhookEvent) error
+}
+
+type IdempotencyRepository interface {
+	CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord, now time.Time) (*models.IdempotencyRecord, error)
+	GetIdempotencyRecord(ctx context.Context, userID, key string, now time.Time) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, recordID primitive.ObjectID) error
+}
+
+// Indexer is implemented by repositories that need indexes created at startup.
+type Indexer interface {
+	EnsureIndexes(ctx context.Context) error
+}
+
+// Transactor runs a unit of work atomically. Repository calls made with the
+// context passed to fn are committed together or not at all.
+type Transactor interface {
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Repositories bundles one implementation of every repository so callers can
+// swap the MongoDB backend for the in-memory one without further changes.
+type Repositories struct {
+	Products        ProductRepository
+	Carts           CartRepository
+	Orders          OrderRepository
+	Users           UserRepository
+	Wishlists       WishlistRepository
+	Coupons         CouponRepository
+	Promotions      PromotionRepository
+	TaxRules        TaxRuleRepository
+	ShippingZones   ShippingZoneRepository
+	Payments        PaymentRepository
+	WebhookEvents   WebhookEventRepository
+	IdempotencyKeys IdempotencyRepository
+	Tx              Transactor
+}
+
+func NewMongoRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Products:        NewMongoProductRepository(db),
+		Carts:           NewMongoCartRepository(db),
+		Orders:          NewMongoOrderRepository(db),
+		Users:           NewMongoUserRepository(db),
+		Wishlists:       NewMongoWishlistRepository(db),
+		Coupons:         NewMongoCouponRepository(db),
+		Promotions:      NewMongoPromotionRepository(db),
+		TaxRules:        NewMongoTaxRuleRepository(db),
+		ShippingZones:   NewMongoShippingZoneRepository(db),
+		Payments:        NewMongoPaymentRepository(db),
+		WebhookEvents:   NewMongoWebhookEventRepository(db),
+		IdempotencyKeys: NewMongoIdempotencyRepository(db),
+		Tx:              NewMongoTransactor(db.Client()),
+	}
+}
+
+// EnsureIndexes creates the indexes of every repository that declares them.
+func (r *Repositories) EnsureIndexes(ctx context.Context) error {
+	for _, repo := range []interface{}{r.Products, r.Carts, r.Orders, r.Users, r.Wishlists, r.Coupons, r.Promotions, r.TaxRules, r.ShippingZones, r.Payments, r.WebhookEvents, r.IdempotencyKeys} {
+		if indexer, ok := repo.(Indexer); ok {
+			if err := indexer.EnsureIndexes(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func NewMemoryRepositories() *Repositories {
+	store := NewMemoryStore()
+	return &Repositories{
+		Products:        NewMemoryProductRepository(store),
+		Carts:           NewMemoryCartRepository(store),
+		Orders:          NewMemoryOrderRepository(store),
+		Users:           NewMemoryUserRepository(store),
+		Wishlists:       NewMemoryWishlistRepository(store),
+		Coupons:         NewMemoryCouponRepository(store),
+		Promotions:      NewMemoryPromotionRepository(store),
+		TaxRules:        NewMemoryTaxRuleRepository(store),
+		ShippingZones:   NewMemoryShippingZoneRepository(store),
+		Payments:        NewMemoryPaymentRepository(store),
+		WebhookEvents:   NewMemoryWebhookEventRepository(store),
+		IdempotencyKeys: NewMemoryIdempotencyRepository(store),
+		Tx:              NewMemoryTransactor(store),
+	}
+}
+
+var (
+	_ ProductRepository      = (*MongoProductRepository)(nil)
+	_ ProductRepository      = (*MemoryProductRepository)(nil)
+	_ CartRepository         = (*MongoCartRepository)(nil)
+	_ CartRepository         = (*MemoryCartRepository)(nil)
+	_ OrderRepository        = (*MongoOrderRepository)(nil)
+	_ OrderRepository        = (*MemoryOrderRepository)(nil)
+	_ UserRepository         = (*MongoUserRepository)(nil)
+	_ UserRepository         = (*MemoryUserRepository)(nil)
+	_ WishlistRepository     = (*MongoWishlistRepository)(nil)
+	_ WishlistRepository     = (*MemoryWishlistRepository)(nil)
+	_ CouponRepository       = (*MongoCouponRepository)(nil)
+	_ CouponRepository       = (*MemoryCouponRepository)(nil)
+	_ PromotionRepository    = (*MongoPromotionRepository)(nil)
+	_ PromotionRepository    = (*MemoryPromotionRepository)(nil)
+	_ TaxRuleRepository      = (*MongoTaxRuleRepository)(nil)
+	_ TaxRuleRepository      = (*MemoryTaxRuleRepository)(nil)
+	_ ShippingZoneRepository = (*MongoShippingZoneRepository)(nil)
+	_ ShippingZoneRepository = (*MemoryShippingZoneRepository)(nil)
+	_ PaymentRepository      = (*MongoPaymentRepository)(nil)
+	_ PaymentRepository      = (*MemoryPaymentRepository)(nil)
+	_ WebhookEventRepository = (*MongoWebhookEventRepository)(nil)
+	_ WebhookEventRepository = (*MemoryWebhookEventRepository)(nil)
+	_ IdempotencyRepository  = (*MongoIdempotencyRepository)(nil)
+	_ IdempotencyRepository  = (*MemoryIdempotencyRepository)(nil)
+	_ Transactor             = (*MongoTransactor)(nil)
+	_ Transactor             = (*MemoryTransactor)(nil)
+)
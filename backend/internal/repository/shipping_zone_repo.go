@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoShippingZoneRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoShippingZoneRepository(db *mongo.Database) *MongoShippingZoneRepository {
+	return &MongoShippingZoneRepository{collection: db.Collection("shipping_zones")}
+}
+
+func (sr *MongoShippingZoneRepository) CreateShippingZone(ctx context.Context, zone *models.ShippingZone) (*models.ShippingZone, error) {
+	result, err := sr.collection.InsertOne(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	zone.ID = result.InsertedID.(primitive.ObjectID)
+	return zone, nil
+}
+
+func (sr *MongoShippingZoneRepository) GetAllShippingZones(ctx context.Context) ([]models.ShippingZone, error) {
+	return sr.find(ctx, bson.M{})
+}
+
+// GetShippingZonesForCountry returns every zone that includes an upper-case
+// country code, whatever its postal prefixes.
+func (sr *MongoShippingZoneRepository) GetShippingZonesForCountry(ctx context.Context, country string) ([]models.ShippingZone, error) {
+	return sr.find(ctx, bson.M{"countries": country})
+}
+
+func (sr *MongoShippingZoneRepository) find(ctx context.Context, filter bson.M) ([]models.ShippingZone, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := sr.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var zones []models.ShippingZone
+	if err = cursor.All(ctx, &zones); err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+func (sr *MongoShippingZoneRepository) UpdateShippingZone(ctx context.Context, zoneID primitive.ObjectID, zone *models.ShippingZone) (*models.ShippingZone, error) {
+	updateData := bson.M{
+		"name":           zone.Name,
+		"countries":      zone.Countries,
+		"postalPrefixes": zone.PostalPrefixes,
+		"methods":        zone.Methods,
+		"updatedAt":      zone.UpdatedAt,
+	}
+
+	result := sr.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": zoneID},
+		bson.M{"$set": updateData},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if err := result.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var updatedZone models.ShippingZone
+	if err := result.Decode(&updatedZone); err != nil {
+		return nil, err
+	}
+
+	return &updatedZone, nil
+}
+
+func (sr *MongoShippingZoneRepository) DeleteShippingZone(ctx context.Context, zoneID primitive.ObjectID) error {
+	_, err := sr.collection.DeleteOne(ctx, bson.M{"_id": zoneID})
+	return err
+}
+
+// EnsureIndexes creates the index backing GetShippingZonesForCountry.
+func (sr *MongoShippingZoneRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := sr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "countries", Value: 1}},
+	})
+	return err
+}
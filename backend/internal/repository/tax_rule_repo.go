@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoTaxRuleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTaxRuleRepository(db *mongo.Database) *MongoTaxRuleRepository {
+	return &MongoTaxRuleRepository{collection: db.Collection("tax_rules")}
+}
+
+func (tr *MongoTaxRuleRepository) CreateTaxRule(ctx context.Context, rule *models.TaxRule) (*models.TaxRule, error) {
+	result, err := tr.collection.InsertOne(ctx, rule)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateTaxRule
+		}
+		return nil, err
+	}
+
+	rule.ID = result.InsertedID.(primitive.ObjectID)
+	return rule, nil
+}
+
+func (tr *MongoTaxRuleRepository) GetAllTaxRules(ctx context.Context) ([]models.TaxRule, error) {
+	return tr.find(ctx, bson.M{})
+}
+
+// GetTaxRulesForCountry returns the country-wide and regional rules of an
+// upper-case country code.
+func (tr *MongoTaxRuleRepository) GetTaxRulesForCountry(ctx context.Context, country string) ([]models.TaxRule, error) {
+	return tr.find(ctx, bson.M{"country": country})
+}
+
+func (tr *MongoTaxRuleRepository) find(ctx context.Context, filter bson.M) ([]models.TaxRule, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "country", Value: 1}, {Key: "region", Value: 1}})
+	cursor, err := tr.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []models.TaxRule
+	if err = cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (tr *MongoTaxRuleRepository) UpdateTaxRule(ctx context.Context, ruleID primitive.ObjectID, rule *models.TaxRule) (*models.TaxRule, error) {
+	updateData := bson.M{
+		"name":          rule.Name,
+		"country":       rule.Country,
+		"region":        rule.Region,
+		"rate":          rule.Rate,
+		"categoryRates": rule.CategoryRates,
+		"inclusive":     rule.Inclusive,
+		"updatedAt":     rule.UpdatedAt,
+	}
+
+	result := tr.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": ruleID},
+		bson.M{"$set": updateData},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if err := result.Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateTaxRule
+		}
+		return nil, err
+	}
+
+	var updatedRule models.TaxRule
+	if err := result.Decode(&updatedRule); err != nil {
+		return nil, err
+	}
+
+	return &updatedRule, nil
+}
+
+func (tr *MongoTaxRuleRepository) DeleteTaxRule(ctx context.Context, ruleID primitive.ObjectID) error {
+	_, err := tr.collection.DeleteOne(ctx, bson.M{"_id": ruleID})
+	return err
+}
+
+// EnsureIndexes allows one rule per country and region. Country-wide rules are
+// stored with an empty region so they take part in the uniqueness check.
+func (tr *MongoTaxRuleRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := tr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "country", Value: 1}, {Key: "region", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
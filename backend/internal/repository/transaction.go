@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoTransactor struct {
+	client *mongo.Client
+}
+
+func NewMongoTransactor(client *mongo.Client) *MongoTransactor {
+	return &MongoTransactor{client: client}
+}
+
+// RunInTransaction executes fn inside a MongoDB session transaction. The context
+// handed to fn carries the session, so every repository call made with it is
+// committed together or rolled back when fn returns an error.
+func (mt *MongoTransactor) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := mt.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
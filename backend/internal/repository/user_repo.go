@@ -3,25 +3,23 @@ package repository
 import (
 	"context"
 
-	"github.com/serikkalibeknur/project-clothesstore/config"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type UserRepository struct {
-	client *mongo.Client
+type MongoUserRepository struct {
+	collection *mongo.Collection
 }
 
-func NewUserRepository(client *mongo.Client) *UserRepository {
-	return &UserRepository{client: client}
+func NewMongoUserRepository(db *mongo.Database) *MongoUserRepository {
+	return &MongoUserRepository{collection: db.Collection("users")}
 }
 
-func (ur *UserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
-	collection := config.GetCollection(ur.client, "users")
-
-	result, err := collection.InsertOne(ctx, user)
+func (ur *MongoUserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	result, err := ur.collection.InsertOne(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -30,11 +28,9 @@ func (ur *UserRepository) CreateUser(ctx context.Context, user *models.User) (*m
 	return user, nil
 }
 
-func (ur *UserRepository) GetUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
-	collection := config.GetCollection(ur.client, "users")
-
+func (ur *MongoUserRepository) GetUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
 	var user models.User
-	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	err := ur.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -45,11 +41,9 @@ func (ur *UserRepository) GetUserByID(ctx context.Context, userID primitive.Obje
 	return &user, nil
 }
 
-func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	collection := config.GetCollection(ur.client, "users")
-
+func (ur *MongoUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := ur.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -60,9 +54,7 @@ func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*mo
 	return &user, nil
 }
 
-func (ur *UserRepository) UpdateUser(ctx context.Context, userID primitive.ObjectID, user *models.User) (*models.User, error) {
-	collection := config.GetCollection(ur.client, "users")
-
+func (ur *MongoUserRepository) UpdateUser(ctx context.Context, userID primitive.ObjectID, user *models.User) (*models.User, error) {
 	updateData := bson.M{
 		"name":      user.Name,
 		"email":     user.Email,
@@ -74,10 +66,11 @@ func (ur *UserRepository) UpdateUser(ctx context.Context, userID primitive.Objec
 		updateData["password"] = user.Password
 	}
 
-	result := collection.FindOneAndUpdate(
+	result := ur.collection.FindOneAndUpdate(
 		ctx,
 		bson.M{"_id": userID},
 		bson.M{"$set": updateData},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
 	)
 
 	if result.Err() != nil {
@@ -92,17 +85,13 @@ func (ur *UserRepository) UpdateUser(ctx context.Context, userID primitive.Objec
 	return &updatedUser, nil
 }
 
-func (ur *UserRepository) DeleteUser(ctx context.Context, userID primitive.ObjectID) error {
-	collection := config.GetCollection(ur.client, "users")
-
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": userID})
+func (ur *MongoUserRepository) DeleteUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := ur.collection.DeleteOne(ctx, bson.M{"_id": userID})
 	return err
 }
 
-func (ur *UserRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
-	collection := config.GetCollection(ur.client, "users")
-
-	cursor, err := collection.Find(ctx, bson.M{})
+func (ur *MongoUserRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	cursor, err := ur.collection.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
@@ -116,10 +105,8 @@ func (ur *UserRepository) GetAllUsers(ctx context.Context) ([]models.User, error
 	return users, nil
 }
 
-func (ur *UserRepository) GetTotalUsers(ctx context.Context) (int64, error) {
-	collection := config.GetCollection(ur.client, "users")
-
-	count, err := collection.CountDocuments(ctx, bson.M{})
+func (ur *MongoUserRepository) GetTotalUsers(ctx context.Context) (int64, error) {
+	count, err := ur.collection.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/serikkalibeknur/project-clothesstore/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoWebhookEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoWebhookEventRepository(db *mongo.Database) *MongoWebhookEventRepository {
+	return &MongoWebhookEventRepository{collection: db.Collection("webhook_events")}
+}
+
+// CreateWebhookEvent stores a newly received event. It returns
+// ErrDuplicateWebhookEvent when the provider already sent an event with the
+// same ID.
+func (wr *MongoWebhookEventRepository) CreateWebhookEvent(ctx context.Context, event *models.WebhookEvent) (*models.WebhookEvent, error) {
+	result, err := wr.collection.InsertOne(ctx, event)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateWebhookEvent
+		}
+		return nil, err
+	}
+
+	event.ID = result.InsertedID.(primitive.ObjectID)
+	return event, nil
+}
+
+func (wr *MongoWebhookEventRepository) GetWebhookEvent(ctx context.Context, provider, eventID string) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	err := wr.collection.FindOne(ctx, bson.M{"provider": provider, "event.id": eventID}).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// GetWebhookEventsByStatus returns the events in status, oldest first.
+func (wr *MongoWebhookEventRepository) GetWebhookEventsByStatus(ctx context.Context, status string) ([]models.WebhookEvent, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "receivedAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := wr.collection.Find(ctx, bson.M{"status": status}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.WebhookEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// UpdateWebhookEvent stores the outcome of processing an event.
+func (wr *MongoWebhookEventRepository) UpdateWebhookEvent(ctx context.Context, event *models.WebhookEvent) error {
+	_, err := wr.collection.UpdateOne(ctx, bson.M{"_id": event.ID}, bson.M{"$set": bson.M{
+		"status":      event.Status,
+		"attempts":    event.Attempts,
+		"lastError":   event.LastError,
+		"processedAt": event.ProcessedAt,
+	}})
+	return err
+}
+
+// EnsureIndexes makes event IDs unique per provider, which is what dedupes
+// provider retries, and backs GetWebhookEventsByStatus.
+func (wr *MongoWebhookEventRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := wr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "event.id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "receivedAt", Value: 1}}},
+	})
+	return err
+}
@@ -4,26 +4,23 @@ import (
 	"context"
 	"time"
 
-	"github.com/serikkalibeknur/project-clothesstore/config"
 	"github.com/serikkalibeknur/project-clothesstore/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type WishlistRepository struct {
-	client *mongo.Client
+type MongoWishlistRepository struct {
+	collection *mongo.Collection
 }
 
-func NewWishlistRepository(client *mongo.Client) *WishlistRepository {
-	return &WishlistRepository{client: client}
+func NewMongoWishlistRepository(db *mongo.Database) *MongoWishlistRepository {
+	return &MongoWishlistRepository{collection: db.Collection("wishlists")}
 }
 
-func (wr *WishlistRepository) GetWishlist(ctx context.Context, userID primitive.ObjectID) (*models.Wishlist, error) {
-	collection := config.GetCollection(wr.client, "wishlists")
-
+func (wr *MongoWishlistRepository) GetWishlist(ctx context.Context, userID primitive.ObjectID) (*models.Wishlist, error) {
 	var wishlist models.Wishlist
-	err := collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&wishlist)
+	err := wr.collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&wishlist)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// Create new wishlist if it doesn't exist
@@ -39,12 +36,10 @@ func (wr *WishlistRepository) GetWishlist(ctx context.Context, userID primitive.
 	return &wishlist, nil
 }
 
-func (wr *WishlistRepository) AddToWishlist(ctx context.Context, userID, productID primitive.ObjectID) error {
-	collection := config.GetCollection(wr.client, "wishlists")
-
+func (wr *MongoWishlistRepository) AddToWishlist(ctx context.Context, userID, productID primitive.ObjectID) error {
 	// Check if wishlist exists
 	var wishlist models.Wishlist
-	err := collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&wishlist)
+	err := wr.collection.FindOne(ctx, bson.M{"userID": userID}).Decode(&wishlist)
 
 	if err != nil && err != mongo.ErrNoDocuments {
 		return err
@@ -63,7 +58,7 @@ func (wr *WishlistRepository) AddToWishlist(ctx context.Context, userID, product
 			},
 			UpdatedAt: time.Now(),
 		}
-		_, err := collection.InsertOne(ctx, newWishlist)
+		_, err := wr.collection.InsertOne(ctx, newWishlist)
 		return err
 	}
 
@@ -83,7 +78,7 @@ func (wr *WishlistRepository) AddToWishlist(ctx context.Context, userID, product
 
 	wishlist.UpdatedAt = time.Now()
 
-	_, err = collection.UpdateOne(
+	_, err = wr.collection.UpdateOne(
 		ctx,
 		bson.M{"userID": userID},
 		bson.M{"$set": bson.M{"products": wishlist.Products, "updatedAt": wishlist.UpdatedAt}},
@@ -92,10 +87,8 @@ func (wr *WishlistRepository) AddToWishlist(ctx context.Context, userID, product
 	return err
 }
 
-func (wr *WishlistRepository) RemoveFromWishlist(ctx context.Context, userID, productID primitive.ObjectID) error {
-	collection := config.GetCollection(wr.client, "wishlists")
-
-	_, err := collection.UpdateOne(
+func (wr *MongoWishlistRepository) RemoveFromWishlist(ctx context.Context, userID, productID primitive.ObjectID) error {
+	_, err := wr.collection.UpdateOne(
 		ctx,
 		bson.M{"userID": userID},
 		bson.M{
@@ -107,10 +100,8 @@ func (wr *WishlistRepository) RemoveFromWishlist(ctx context.Context, userID, pr
 	return err
 }
 
-func (wr *WishlistRepository) ClearWishlist(ctx context.Context, userID primitive.ObjectID) error {
-	collection := config.GetCollection(wr.client, "wishlists")
-
-	_, err := collection.UpdateOne(
+func (wr *MongoWishlistRepository) ClearWishlist(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := wr.collection.UpdateOne(
 		ctx,
 		bson.M{"userID": userID},
 		bson.M{"$set": bson.M{"products": []models.WishlistItem{}, "updatedAt": time.Now()}},
@@ -119,9 +110,7 @@ func (wr *WishlistRepository) ClearWishlist(ctx context.Context, userID primitiv
 	return err
 }
 
-func (wr *WishlistRepository) DeleteWishlist(ctx context.Context, userID primitive.ObjectID) error {
-	collection := config.GetCollection(wr.client, "wishlists")
-
-	_, err := collection.DeleteOne(ctx, bson.M{"userID": userID})
+func (wr *MongoWishlistRepository) DeleteWishlist(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := wr.collection.DeleteOne(ctx, bson.M{"userID": userID})
 	return err
 }
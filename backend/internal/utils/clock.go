@@ -0,0 +1,14 @@
+package utils
+
+import "time"
+
+// Clock abstracts the current time so handlers can be driven by a fixed clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -4,42 +4,77 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/serikkalibeknur/project-clothesstore/internal/controllers"
 	"github.com/serikkalibeknur/project-clothesstore/internal/middleware"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetupRoutes(router *mux.Router, client *mongo.Client) {
+func SetupRoutes(router *mux.Router, app *controllers.App) {
 	api := router.PathPrefix("/api").Subrouter()
+	auth := middleware.AuthMiddleware(app.Config.JWTSecret)
+	idempotent := middleware.Idempotency(app.IdempotencyKeys, app.Clock, app.Config.IdempotencyTTL, app.Logger)
 
 	// Auth routes
-	api.HandleFunc("/auth/register", middleware.LoggerMiddleware(controllers.Register(client))).Methods("POST")
-	api.HandleFunc("/auth/login", middleware.LoggerMiddleware(controllers.Login(client))).Methods("POST")
+	api.HandleFunc("/auth/register", middleware.LoggerMiddleware(app.Register)).Methods("POST")
+	api.HandleFunc("/auth/login", middleware.LoggerMiddleware(app.Login)).Methods("POST")
 
 	// Product routes (public)
-	api.HandleFunc("/products", middleware.LoggerMiddleware(controllers.GetAllProducts(client))).Methods("GET")
-	api.HandleFunc("/products/{id}", middleware.LoggerMiddleware(controllers.GetProductByID(client))).Methods("GET")
+	api.HandleFunc("/products", middleware.LoggerMiddleware(app.GetAllProducts)).Methods("GET")
+	api.HandleFunc("/products/search", middleware.LoggerMiddleware(app.SearchProducts)).Methods("GET")
+	api.HandleFunc("/products/{id}", middleware.LoggerMiddleware(app.GetProductByID)).Methods("GET")
 
 	// Product routes (admin only)
-	api.HandleFunc("/products", middleware.LoggerMiddleware(middleware.AuthMiddleware(middleware.RequireAdmin(controllers.CreateProduct(client))))).Methods("POST")
-	api.HandleFunc("/products/{id}", middleware.LoggerMiddleware(middleware.AuthMiddleware(middleware.RequireAdmin(controllers.UpdateProduct(client))))).Methods("PUT")
-	api.HandleFunc("/products/{id}", middleware.LoggerMiddleware(middleware.AuthMiddleware(middleware.RequireAdmin(controllers.DeleteProduct(client))))).Methods("DELETE")
+	api.HandleFunc("/products", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.CreateProduct)))).Methods("POST")
+	api.HandleFunc("/products/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.UpdateProduct)))).Methods("PUT")
+	api.HandleFunc("/products/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.DeleteProduct)))).Methods("DELETE")
 
 	// Cart routes (protected)
-	api.HandleFunc("/cart", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.GetCart(client)))).Methods("GET")
-	api.HandleFunc("/cart", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.AddToCart(client)))).Methods("POST")
-	api.HandleFunc("/cart", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.UpdateCart(client)))).Methods("PUT")
-	api.HandleFunc("/cart/{productID}", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.RemoveFromCart(client)))).Methods("DELETE")
+	api.HandleFunc("/cart", middleware.LoggerMiddleware(auth(app.GetCart))).Methods("GET")
+	api.HandleFunc("/cart", middleware.LoggerMiddleware(auth(idempotent(app.AddToCart)))).Methods("POST")
+	api.HandleFunc("/cart/coupon", middleware.LoggerMiddleware(auth(app.ApplyCoupon))).Methods("POST")
+	api.HandleFunc("/cart/coupon", middleware.LoggerMiddleware(auth(app.RemoveCoupon))).Methods("DELETE")
+	api.HandleFunc("/cart/items/{lineID}", middleware.LoggerMiddleware(auth(app.UpdateCartItem))).Methods("PUT")
+	api.HandleFunc("/cart/items/{lineID}", middleware.LoggerMiddleware(auth(app.RemoveCartItem))).Methods("DELETE")
+	api.HandleFunc("/cart/{productID}", middleware.LoggerMiddleware(auth(app.RemoveFromCart))).Methods("DELETE")
+
+	// Shipping routes (protected)
+	api.HandleFunc("/shipping/quotes", middleware.LoggerMiddleware(auth(app.GetShippingQuotes))).Methods("GET")
 
 	// Order routes (protected)
-	api.HandleFunc("/orders", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.CreateOrder(client)))).Methods("POST")
-	api.HandleFunc("/orders", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.GetUserOrders(client)))).Methods("GET")
+	api.HandleFunc("/orders", middleware.LoggerMiddleware(auth(idempotent(app.CreateOrder)))).Methods("POST")
+	api.HandleFunc("/orders", middleware.LoggerMiddleware(auth(app.GetUserOrders))).Methods("GET")
+	api.HandleFunc("/orders/{id}", middleware.LoggerMiddleware(auth(app.GetOrder))).Methods("GET")
+	api.HandleFunc("/orders/{id}/cancel", middleware.LoggerMiddleware(auth(app.CancelOrder))).Methods("POST")
+	api.HandleFunc("/orders/{id}/payment", middleware.LoggerMiddleware(auth(app.GetOrderPayment))).Methods("GET")
+
+	// Payment provider webhooks, authenticated by their signature
+	api.HandleFunc("/webhooks/payments/{provider}", middleware.LoggerMiddleware(app.PaymentWebhook)).Methods("POST")
 
 	// Wishlist routes (protected)
-	api.HandleFunc("/wishlist", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.GetWishlist(client)))).Methods("GET")
-	api.HandleFunc("/wishlist", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.AddToWishlist(client)))).Methods("POST")
-	api.HandleFunc("/wishlist/{productID}", middleware.LoggerMiddleware(middleware.AuthMiddleware(controllers.RemoveFromWishlist(client)))).Methods("DELETE")
+	api.HandleFunc("/wishlist", middleware.LoggerMiddleware(auth(app.GetWishlist))).Methods("GET")
+	api.HandleFunc("/wishlist", middleware.LoggerMiddleware(auth(app.AddToWishlist))).Methods("POST")
+	api.HandleFunc("/wishlist/{productID}", middleware.LoggerMiddleware(auth(app.RemoveFromWishlist))).Methods("DELETE")
 
 	// Admin routes (protected, admin only)
-	api.HandleFunc("/admin/statistics", middleware.LoggerMiddleware(middleware.AuthMiddleware(middleware.RequireAdmin(controllers.GetStatistics(client))))).Methods("GET")
-	api.HandleFunc("/admin/orders", middleware.LoggerMiddleware(middleware.AuthMiddleware(middleware.RequireAdmin(controllers.GetAllOrders(client))))).Methods("GET")
-	api.HandleFunc("/admin/orders/{id}", middleware.LoggerMiddleware(middleware.AuthMiddleware(middleware.RequireAdmin(controllers.UpdateOrderStatus(client))))).Methods("PUT")
+	api.HandleFunc("/admin/coupons", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetAllCoupons)))).Methods("GET")
+	api.HandleFunc("/admin/coupons", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.CreateCoupon)))).Methods("POST")
+	api.HandleFunc("/admin/coupons/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.UpdateCoupon)))).Methods("PUT")
+	api.HandleFunc("/admin/coupons/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.DeleteCoupon)))).Methods("DELETE")
+	api.HandleFunc("/admin/promotions", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetAllPromotions)))).Methods("GET")
+	api.HandleFunc("/admin/promotions", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.CreatePromotion)))).Methods("POST")
+	api.HandleFunc("/admin/promotions/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.UpdatePromotion)))).Methods("PUT")
+	api.HandleFunc("/admin/promotions/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.DeletePromotion)))).Methods("DELETE")
+	api.HandleFunc("/admin/tax-rules", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetAllTaxRules)))).Methods("GET")
+	api.HandleFunc("/admin/tax-rules", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.CreateTaxRule)))).Methods("POST")
+	api.HandleFunc("/admin/tax-rules/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.UpdateTaxRule)))).Methods("PUT")
+	api.HandleFunc("/admin/tax-rules/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.DeleteTaxRule)))).Methods("DELETE")
+	api.HandleFunc("/admin/shipping-zones", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetAllShippingZones)))).Methods("GET")
+	api.HandleFunc("/admin/shipping-zones", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.CreateShippingZone)))).Methods("POST")
+	api.HandleFunc("/admin/shipping-zones/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.UpdateShippingZone)))).Methods("PUT")
+	api.HandleFunc("/admin/shipping-zones/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.DeleteShippingZone)))).Methods("DELETE")
+	api.HandleFunc("/admin/payments/{id}/capture", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(idempotent(app.CapturePayment))))).Methods("POST")
+	api.HandleFunc("/admin/payments/{id}/refund", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(idempotent(app.RefundPayment))))).Methods("POST")
+	api.HandleFunc("/admin/statistics", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetStatistics)))).Methods("GET")
+	api.HandleFunc("/admin/analytics", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetAnalytics)))).Methods("GET")
+	api.HandleFunc("/admin/reports", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetReports)))).Methods("GET")
+	api.HandleFunc("/admin/reports/customers", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetCustomerReport)))).Methods("GET")
+	api.HandleFunc("/admin/orders", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.GetAllOrders)))).Methods("GET")
+	api.HandleFunc("/admin/orders/{id}", middleware.LoggerMiddleware(auth(middleware.RequireAdmin(app.UpdateOrderStatus)))).Methods("PUT")
 }
@@ -29,33 +29,33 @@ function showSection(id) {
 }
 
 async function loadDashboard() {
-    const [p, o, u] = await Promise.all([apiCall('/products'), apiCall('/orders'), apiCall('/users')]);
+    const [p, o, u] = await Promise.all([apiCall('/products'), apiCall('/admin/orders?limit=5'), apiCall('/users')]);
     
-    if (p.success) document.getElementById('total-products').textContent = p.data.length;
+    if (p.success) document.getElementById('total-products').textContent = p.data.total;
     if (u.success) document.getElementById('total-users').textContent = u.data.length;
     
     if (o.success) {
-        document.getElementById('total-orders').textContent = o.data.length;
-        const rev = o.data.slice(0, 5).reduce((s, x) => s + (x.total || 0), 0);
+        document.getElementById('total-orders').textContent = o.data.total;
+        const rev = o.data.items.reduce((s, x) => s + moneyToNumber(x.totalPrice), 0);
         document.getElementById('total-revenue').textContent = formatCurrency(rev);
         
         const tbody = document.getElementById('recent-orders');
-        tbody.innerHTML = o.data.slice(0, 5).map(item => `
+        tbody.innerHTML = o.data.items.map(item => `
             <tr>
                 <td>${item.id.substring(0, 8)}</td>
-                <td>${item.user_name || 'N/A'}</td>
-                <td>${formatCurrency(item.total || 0)}</td>
+                <td>${item.userID.substring(0, 8)}</td>
+                <td>${formatCurrency(item.totalPrice || 0)}</td>
                 <td><span class="status-badge status-${item.status}">${item.status}</span></td>
-                <td>${formatDate(item.created_at)}</td>
+                <td>${formatDate(item.createdAt)}</td>
             </tr>
         `).join('');
     }
 }
 
 async function loadProducts() {
-    const data = await apiCall('/products');
+    const data = await apiCall('/products?limit=100');
     if (data.success && data.data) {
-        document.getElementById('products-table').innerHTML = data.data.map(p => `
+        document.getElementById('products-table').innerHTML = data.data.items.map(p => `
             <tr>
                 <td>${p.id.substring(0, 8)}</td>
                 <td>${p.name}</td>
@@ -70,16 +70,16 @@ async function loadProducts() {
 }
 
 async function loadOrders() {
-    const data = await apiCall('/orders');
+    const data = await apiCall('/admin/orders?limit=100');
     if (data.success && data.data) {
-        document.getElementById('orders-table').innerHTML = data.data.map(o => `
+        document.getElementById('orders-table').innerHTML = data.data.items.map(o => `
             <tr>
                 <td>${o.id.substring(0, 8)}</td>
-                <td>${o.user_name || 'N/A'}</td>
-                <td>${formatCurrency(o.total || 0)}</td>
-                <td>${o.items?.length || 0}</td>
+                <td>${o.userID.substring(0, 8)}</td>
+                <td>${formatCurrency(o.totalPrice || 0)}</td>
+                <td>${o.products?.length || 0}</td>
                 <td><span class="status-badge status-${o.status}">${o.status}</span></td>
-                <td>${formatDate(o.created_at)}</td>
+                <td>${formatDate(o.createdAt)}</td>
                 <td><button class="btn btn-sm btn-edit" onclick="editOrder('${o.id}')">Edit</button><button class="btn btn-sm btn-delete" onclick="deleteOrder('${o.id}')">Delete</button></td>
             </tr>
         `).join('');
@@ -133,14 +133,14 @@ async function handleProductSubmit(e) {
     e.preventDefault();
     const product = {
         name: document.getElementById('modal-name').value,
-        price: parseFloat(document.getElementById('modal-price').value),
+        price: toMoney(parseFloat(document.getElementById('modal-price').value) || 0),
         category: document.getElementById('modal-category').value,
         stock: parseInt(document.getElementById('modal-stock').value),
         imageURL: document.getElementById('modal-image').value,
         description: document.getElementById('modal-description').value
     };
     
-    if (!product.name || !product.price || !product.category) {
+    if (!product.name || !product.price.amount || !product.category) {
         showNotification('Please fill in all required fields', 'error');
         return;
     }
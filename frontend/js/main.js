@@ -89,7 +89,10 @@ async function apiCall(endpoint, method = 'GET', data = null) {
 }
 
 // Formatting
-const formatCurrency = (n) => new Intl.NumberFormat('en-US', { style: 'currency', currency: 'USD' }).format(n);
+// The API sends money as { amount, currency } in minor units (cents)
+const moneyToNumber = (m) => (m && typeof m === 'object') ? m.amount / 100 : (m || 0);
+const toMoney = (n) => ({ amount: Math.round(n * 100), currency: 'USD' });
+const formatCurrency = (m) => new Intl.NumberFormat('en-US', { style: 'currency', currency: m?.currency || 'USD' }).format(moneyToNumber(m));
 const formatDate = (d) => new Date(d).toLocaleDateString('en-US', { year: 'numeric', month: 'short', day: 'numeric' });
 
 // Initialize
@@ -2,9 +2,13 @@ let allProducts = [];
 let currentProduct = null;
 
 async function fetchProducts() {
-    const data = await apiCall('/products');
+    const params = new URLSearchParams({ limit: 100 });
+    const category = document.getElementById('category')?.value;
+    if (category) params.set('category', category);
+
+    const data = await apiCall(`/products?${params}`);
     if (data.success && data.data) {
-        allProducts = data.data;
+        allProducts = data.data.items;
         displayProducts(allProducts);
     } else {
         showNotification('Failed to load products', 'error');
@@ -33,7 +37,7 @@ function displayProducts(products) {
             <img src="${p.imageURL || 'https://via.placeholder.com/250'}" alt="${p.name}" class="product-image">
             <div class="product-info">
                 <h3 class="product-title">${p.name}</h3>
-                <p class="product-price">${formatCurrency(p.price)}</p>
+                <p class="product-price">${priceHTML(p)}</p>
                 <p class="product-category">${p.category}</p>
                 <div class="product-actions">
                     <button class="btn btn-primary" onclick="event.stopPropagation(); addToCartDirect('${p.id}')">Add to Cart</button>
@@ -44,6 +48,16 @@ function displayProducts(products) {
     `).join('');
 }
 
+// currentPrice is what the product sells for right now, sale included.
+function currentPrice(p) {
+    return p.effectivePrice ?? p.price;
+}
+
+function priceHTML(p) {
+    if (!p.onSale) return formatCurrency(p.price);
+    return `${formatCurrency(p.effectivePrice)} <span class="old-price">${formatCurrency(p.price)}</span>`;
+}
+
 function displayProductDetail(p) {
     document.title = `${p.name} - Clothes Store`;
     const bc = document.getElementById('product-breadcrumb');
@@ -51,7 +65,7 @@ function displayProductDetail(p) {
     
     const els = {
         'product-title': p.name,
-        'product-price': formatCurrency(p.price),
+        'product-price': formatCurrency(currentPrice(p)),
         'product-category': p.category,
         'product-description': p.description,
         'product-sku': p.id.substring(0, 8).toUpperCase(),
@@ -62,6 +76,12 @@ function displayProductDetail(p) {
         if (el) el.textContent = val;
     });
     
+    const oldPrice = document.getElementById('product-old-price');
+    if (oldPrice) {
+        oldPrice.textContent = p.onSale ? formatCurrency(p.price) : '';
+        oldPrice.style.display = p.onSale ? 'inline' : 'none';
+    }
+    
     const img = document.getElementById('main-image');
     if (img) img.src = p.imageURL || 'https://via.placeholder.com/500';
     
@@ -73,9 +93,9 @@ function displayProductDetail(p) {
 }
 
 async function fetchRelatedProducts(category, excludeId) {
-    const data = await apiCall(`/products?category=${category}`);
+    const data = await apiCall(`/products?category=${encodeURIComponent(category)}&limit=5`);
     if (data.success && data.data) {
-        displayRelatedProducts(data.data.filter(p => p.id !== excludeId).slice(0, 4));
+        displayRelatedProducts(data.data.items.filter(p => p.id !== excludeId).slice(0, 4));
     }
 }
 
@@ -88,7 +108,7 @@ function displayRelatedProducts(products) {
             <img src="${p.imageURL || 'https://via.placeholder.com/250'}" alt="${p.name}" class="product-image">
             <div class="product-info">
                 <h3 class="product-title">${p.name}</h3>
-                <p class="product-price">${formatCurrency(p.price)}</p>
+                <p class="product-price">${priceHTML(p)}</p>
                 <p class="product-category">${p.category}</p>
                 <button class="btn btn-primary" style="width: 100%;" onclick="event.stopPropagation(); addToCartDirect('${p.id}')">Add to Cart</button>
             </div>
@@ -96,15 +116,17 @@ function displayRelatedProducts(products) {
     `).join('');
 }
 
-function filterProducts() {
-    const category = document.getElementById('category').value;
-    const search = document.getElementById('search').value.toLowerCase();
-    
-    let filtered = allProducts.filter(p => 
-        (!category || p.category === category) && 
-        (!search || p.name.toLowerCase().includes(search) || p.description.toLowerCase().includes(search))
-    );
-    displayProducts(filtered);
+async function searchProducts() {
+    const q = document.getElementById('search').value.trim();
+    if (!q) return fetchProducts();
+
+    const data = await apiCall(`/products/search?${new URLSearchParams({ q, limit: 100 })}`);
+    if (data.success && data.data) {
+        allProducts = data.data.items;
+        displayProducts(allProducts);
+    } else {
+        showNotification('Search failed', 'error');
+    }
 }
 
 function viewProduct(id) { 
@@ -118,7 +140,7 @@ function addToCartDirect(id) {
     
     const item = cart.find(i => i.id === id);
     if (item) item.quantity += 1;
-    else cart.push({ id, name: product.name, price: product.price, imageURL: product.imageURL, quantity: 1, size: 'M', color: 'Black' });
+    else cart.push({ id, name: product.name, price: moneyToNumber(currentPrice(product)), imageURL: product.imageURL, quantity: 1, size: 'M', color: 'Black' });
     
     localStorage.setItem('cart', JSON.stringify(cart));
     updateCartCount();
@@ -144,7 +166,7 @@ function addToCart(id) {
         item.quantity += quantity;
         showNotification(`${product.name} quantity updated!`, 'success');
     } else {
-        cart.push({ id, name: product.name, price: product.price, imageURL: product.imageURL, quantity, size, color });
+        cart.push({ id, name: product.name, price: moneyToNumber(currentPrice(product)), imageURL: product.imageURL, quantity, size, color });
         showNotification(`${product.name} added to cart!`, 'success');
     }
     
@@ -165,7 +187,7 @@ function addToWishlist(id) {
         wishlist.splice(idx, 1);
         showNotification(`${product.name} removed from favorites`, 'info');
     } else {
-        wishlist.push({ id, name: product.name, price: product.price, imageURL: product.imageURL });
+        wishlist.push({ id, name: product.name, price: moneyToNumber(currentPrice(product)), imageURL: product.imageURL });
         showNotification(`${product.name} added to favorites!`, 'success');
     }
     localStorage.setItem('wishlist', JSON.stringify(wishlist));
@@ -183,9 +205,9 @@ document.addEventListener('DOMContentLoaded', () => {
     const container = document.getElementById('products-container');
     if (container && !window.location.pathname.includes('product.html')) {
         fetchProducts();
-        document.getElementById('category')?.addEventListener('change', filterProducts);
-        document.getElementById('search')?.addEventListener('input', filterProducts);
-        document.querySelector('.btn-search')?.addEventListener('click', filterProducts);
+        document.getElementById('category')?.addEventListener('change', fetchProducts);
+        document.getElementById('search')?.addEventListener('keydown', e => { if (e.key === 'Enter') searchProducts(); });
+        document.querySelector('.btn-search')?.addEventListener('click', searchProducts);
     } else if (window.location.pathname.includes('product.html')) {
         const id = new URLSearchParams(window.location.search).get('id');
         if (id) fetchProduct(id);
@@ -7,13 +7,16 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 	"github.com/serikkalibeknur/project-clothesstore/config"
+	"github.com/serikkalibeknur/project-clothesstore/internal/controllers"
+	"github.com/serikkalibeknur/project-clothesstore/internal/payments"
+	"github.com/serikkalibeknur/project-clothesstore/internal/repository"
+	"github.com/serikkalibeknur/project-clothesstore/internal/utils"
 	"github.com/serikkalibeknur/project-clothesstore/routes"
 )
 
@@ -23,36 +26,51 @@ func main() {
 		log.Println("No .env file found, using defaults")
 	}
 
+	cfg := config.Load()
+
 	// Connect to MongoDB
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := config.ConnectDB(ctx)
+	client, err := config.ConnectDB(ctx, cfg.MongoURI)
 	if err != nil {
 		log.Fatal("Failed to connect to MongoDB:", err)
 	}
-	defer client.Disconnect(ctx)
+	defer client.Disconnect(context.Background())
+
+	repos := repository.NewMongoRepositories(client.Database(cfg.DBName))
+	if err := repos.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create indexes:", err)
+	}
+
+	providers := payments.NewRegistry(payments.NewFakeProvider(cfg.PaymentWebhookSecret))
+	if _, ok := providers[cfg.PaymentProvider]; !ok {
+		log.Fatalf("Unknown payment provider %q", cfg.PaymentProvider)
+	}
+	if cfg.PaymentWebhookSecret == "" {
+		log.Println("PAYMENT_WEBHOOK_SECRET is not set, payment webhooks will be rejected")
+	}
+
+	app := controllers.NewApp(cfg, repos, log.Default(), utils.SystemClock{}, providers)
 
 	// Initialize router
 	router := mux.NewRouter()
 
 	// Setup routes
-	routes.SetupRoutes(router, client)
+	routes.SetupRoutes(router, app)
 
 	// CORS configuration
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "Idempotency-Key"},
+		ExposedHeaders: []string{"Idempotent-Replayed"},
 	})
 
 	handler := c.Handler(router)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := cfg.Port
 
 	// Print startup information
 	fmt.Println("")